@@ -0,0 +1,42 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import "context"
+
+// Reader is implemented by every session type: Session, LoginSession and
+// OAuthSession. Code depending on it works with any authentication mode and
+// can be given a fake in tests.
+type Reader interface {
+	FrontpageContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error)
+	SubredditSubmissionsContext(ctx context.Context, subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error)
+	SubredditCommentsContext(ctx context.Context, subreddit string, params ListingOptions) ([]*Comment, error)
+	AboutSubredditContext(ctx context.Context, subreddit string) (*Subreddit, error)
+	AboutRedditorContext(ctx context.Context, username string) (*Redditor, error)
+	RedditorSubmissionsContext(ctx context.Context, username string, params ListingOptions) ([]*Submission, error)
+	RedditorCommentsContext(ctx context.Context, username string, params ListingOptions) ([]*Comment, error)
+	CommentsContext(ctx context.Context, h *Submission, sort PopularitySort, params ListingOptions) ([]*Comment, error)
+	ThreadContext(ctx context.Context, submissionID string, sort PopularitySort, params ListingOptions) (*Thread, error)
+	ExpandMoreContext(ctx context.Context, t *Thread, more []*MoreComments, sort PopularitySort) error
+	ExpandThreadContext(ctx context.Context, t *Thread, sort PopularitySort) error
+}
+
+// Writer is implemented by the sessions acting on behalf of a user:
+// LoginSession and OAuthSession.
+type Writer interface {
+	MeContext(ctx context.Context) (*Redditor, error)
+	SubmitContext(ctx context.Context, ns *NewSubmission) (*Submission, error)
+	ReplyContext(ctx context.Context, r Replier, comment string) (*Comment, error)
+	VoteContext(ctx context.Context, v Voter, dir Vote) error
+	SaveContext(ctx context.Context, v Voter, category string) error
+	UnsaveContext(ctx context.Context, v Voter, category string) error
+	DeleteContext(ctx context.Context, d Deleter) error
+}
+
+// Client is implemented by LoginSession and OAuthSession.
+type Client interface {
+	Reader
+	Writer
+}
@@ -0,0 +1,92 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	_ Reader = Session{}
+	_ Client = LoginSession{}
+	_ Client = (*OAuthSession)(nil)
+)
+
+func TestLoginSessionWriter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/submit":
+			fmt.Fprintln(w, `{"json": {"errors": [], "data": {"id": "abc", "name": "t3_abc", "url": "https://www.reddit.com/r/golang/comments/abc/"}}}`)
+		case "/api/comment":
+			fmt.Fprintln(w, `{"json": {"errors": [], "data": {"things": [{"kind": "t1", "data": {"id": "def", "name": "t1_def", "body": "hi"}}]}}}`)
+		}
+	}))
+	defer server.Close()
+
+	var c Client = LoginSession{Session: Session{opts: newOptions([]Option{WithBaseURL(server.URL)})}}
+	s, err := c.SubmitContext(t.Context(), NewTextSubmission("golang", "title", "text", true, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.FullID != "t3_abc" {
+		t.Fatalf("SubmitContext() returned unexpected submission: %+v", s)
+	}
+
+	comment, err := c.ReplyContext(t.Context(), s, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.FullID != "t1_def" || comment.Body != "hi" {
+		t.Fatalf("ReplyContext() returned unexpected comment: %+v", comment)
+	}
+}
+
+func TestLoginSessionUnexpectedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/vote":
+			fmt.Fprintln(w, `{"message": "Forbidden", "reason": "SUBREDDIT_ARCHIVED"}`)
+		case "/api/comment":
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintln(w, `{"json": {"errors": [], "data": {"things": []}}}`)
+		case "/api/del":
+			fmt.Fprint(w, `<html>`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	s := LoginSession{Session: Session{opts: newOptions([]Option{WithBaseURL(server.URL)})}}
+	sub := &Submission{FullID: "t3_abc"}
+
+	var apiErr *APIError
+	err := s.VoteContext(t.Context(), sub, UpVote)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Code != "SUBREDDIT_ARCHIVED" || apiErr.Message != "failed to vote: Forbidden" {
+		t.Fatalf("VoteContext() returned unexpected error: %#v", err)
+	}
+
+	_, err = s.ReplyContext(t.Context(), sub, "hi")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusAccepted || !strings.HasPrefix(apiErr.Message, "failed to post comment: unexpected response") {
+		t.Fatalf("ReplyContext() returned unexpected error: %#v", err)
+	}
+
+	err = s.DeleteContext(t.Context(), sub)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Message != `failed to delete item: unexpected response "<html>"` {
+		t.Fatalf("DeleteContext() returned unexpected error: %#v", err)
+	}
+
+	err = s.ClearContext(t.Context())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Message != `failed to clear session: unexpected response "{}"` {
+		t.Fatalf("ClearContext() returned unexpected error: %#v", err)
+	}
+}
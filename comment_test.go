@@ -0,0 +1,141 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const threadJSON = `[
+	{"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {"id": "abc", "name": "t3_abc", "title": "A thread"}}]}},
+	{"kind": "Listing", "data": {"children": [
+		{"kind": "t1", "data": {"name": "t1_a", "body": "top", "parent_id": "t3_abc", "replies": {"kind": "Listing", "data": {"children": [
+			{"kind": "t1", "data": {"name": "t1_b", "body": "reply", "parent_id": "t1_a", "replies": ""}},
+			{"kind": "more", "data": {"id": "c", "name": "t1_c", "parent_id": "t1_a", "count": 2, "depth": 1, "children": ["c", "d"]}}
+		]}}}},
+		{"kind": "more", "data": {"id": "e", "name": "t1_e", "parent_id": "t3_abc", "count": 5, "depth": 0, "children": ["e", "f", "g"]}}
+	]}}
+]`
+
+func TestThread(t *testing.T) {
+	server, oauth := testTools(200, threadJSON)
+	defer server.Close()
+
+	thread, err := oauth.Thread("abc", DefaultPopularity, ListingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Submission.Title != "A thread" {
+		t.Fatalf("unexpected submission: %v", thread.Submission)
+	}
+	if len(thread.Comments) != 1 || thread.Comments[0].Body != "top" {
+		t.Fatalf("unexpected top-level comments: %v", thread.Comments)
+	}
+	if len(thread.More) != 1 || thread.More[0].Count != 5 || len(thread.More[0].Children) != 3 {
+		t.Fatalf("unexpected top-level more: %+v", thread.More)
+	}
+
+	top := thread.Comments[0]
+	if len(top.Replies) != 1 || top.Replies[0].Body != "reply" || len(top.Replies[0].Replies) != 0 {
+		t.Fatalf("unexpected replies: %v", top.Replies)
+	}
+	if len(top.More) != 1 || top.More[0].ParentID != "t1_a" || top.More[0].Depth != 1 {
+		t.Fatalf("unexpected more replies: %+v", top.More)
+	}
+}
+
+func TestThreadUnexpectedResponse(t *testing.T) {
+	for _, body := range []string{`[]`, `[{"data": {"children": []}}, {"data": {"children": []}}]`} {
+		server, oauth := testTools(200, body)
+		_, err := oauth.Thread("abc", DefaultPopularity, ListingOptions{})
+		server.Close()
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK {
+			t.Errorf("%s: Thread() returned unexpected error: %#v", body, err)
+		}
+	}
+}
+
+func TestExpandThread(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/morechildren" {
+			fmt.Fprintln(w, threadJSON)
+			return
+		}
+		if r.URL.Query().Get("link_id") != "t3_abc" {
+			t.Errorf("unexpected link_id: %s", r.URL.Query().Get("link_id"))
+		}
+		requests = append(requests, r.URL.Query().Get("children"))
+		fmt.Fprintln(w, `{"json": {"errors": [], "data": {"things": [
+			{"kind": "t1", "data": {"name": "t1_d", "body": "d", "parent_id": "t1_c"}},
+			{"kind": "t1", "data": {"name": "t1_c", "body": "c", "parent_id": "t1_a"}},
+			{"kind": "t1", "data": {"name": "t1_e", "body": "e", "parent_id": "t3_abc"}},
+			{"kind": "t1", "data": {"name": "t1_f", "body": "f", "parent_id": "t3_abc"}},
+			{"kind": "t1", "data": {"name": "t1_g", "body": "g", "parent_id": "t3_abc"}}
+		]}}}`)
+	}))
+	defer server.Close()
+
+	o := &OAuthSession{Client: server.Client(), opts: newOptions([]Option{WithOAuthURL(server.URL)})}
+	thread, err := o.Thread("abc", DefaultPopularity, ListingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.ExpandThread(thread, DefaultPopularity); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 1 || requests[0] != "e,f,g,c,d" {
+		t.Fatalf("unexpected morechildren requests: %q", requests)
+	}
+	if len(thread.More) != 0 || len(thread.Comments) != 4 {
+		t.Fatalf("unexpected top-level comments: %v, more: %v", thread.Comments, thread.More)
+	}
+	top := thread.Comments[0]
+	if len(top.More) != 0 || len(top.Replies) != 2 || top.Replies[1].Body != "c" {
+		t.Fatalf("unexpected replies: %v", top.Replies)
+	}
+	if len(top.Replies[1].Replies) != 1 || top.Replies[1].Replies[0].Body != "d" {
+		t.Fatalf("unexpected nested replies: %v", top.Replies[1].Replies)
+	}
+}
+
+func TestCommentJSON(t *testing.T) {
+	const raw = `{
+		"id": "c1", "name": "t1_c1", "author": "gopher", "author_fullname": "t2_g",
+		"edited": 1600000000, "likes": true, "banned_by": "mod", "num_reports": 2,
+		"depth": 0, "distinguished": "moderator", "stickied": true, "is_submitter": true,
+		"score_hidden": false, "controversiality": 1, "collapsed": false,
+		"replies": {"kind": "Listing", "data": {"children": [
+			{"kind": "t1", "data": {"name": "t1_c2", "depth": 1, "edited": false, "likes": null, "replies": ""}},
+			{"kind": "more", "data": {"name": "t1_c3", "parent_id": "t1_c1", "count": 4, "children": ["c3", "c4"]}}
+		]}}
+	}`
+
+	var c Comment
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Likes == nil || !*c.Likes || c.BannedBy == nil || *c.BannedBy != "mod" || c.NumReports == nil || *c.NumReports != 2 {
+		t.Errorf("unexpected pointer fields: %v, %v, %v", c.Likes, c.BannedBy, c.NumReports)
+	}
+	if !c.Edited.Time.Equal(time.Unix(1600000000, 0)) || c.AuthorFullID != "t2_g" || c.Distinguished != "moderator" || !c.IsSubmitter || c.Controversiality != 1 {
+		t.Errorf("unexpected comment: %+v", c)
+	}
+	if len(c.Replies) != 1 || c.Replies[0].Depth != 1 || c.Replies[0].Likes != nil || c.Replies[0].Edited.Edited {
+		t.Fatalf("unexpected replies: %+v", c.Replies)
+	}
+	if len(c.More) != 1 || c.More[0].Count != 4 {
+		t.Fatalf("unexpected more comments: %+v", c.More)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// APIError represents an error reported by reddit.com, either through the
+// HTTP status of a response or through the errors of an api_type=json
+// response. Use errors.As to inspect it.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is reddit's error code, e.g. "RATELIMIT" or "SUBREDDIT_NOEXIST".
+	Code string
+	// Field is the name of the form field the error relates to, if any.
+	Field string
+	// Message is the human readable explanation of the error.
+	Message string
+	// RetryAfter is how long reddit asks to wait before retrying, if known.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	if e.Field != "" {
+		msg += " (" + e.Field + ")"
+	}
+	return msg
+}
+
+// responseError returns an *APIError if resp has a non-2xx status code.
+func responseError(resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    resp.Status,
+		RetryAfter: retryAfter(resp.Header),
+	}
+
+	// Reddit usually explains the failure in a small JSON object.
+	var r struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	if json.Unmarshal(body, &r) == nil {
+		if r.Message != "" {
+			e.Message = r.Message
+		}
+		e.Code = r.Reason
+	}
+	return e
+}
+
+// jsonError returns an *APIError for the first entry of json.errors in an
+// api_type=json response body, or nil if there is none.
+func jsonError(statusCode int, body []byte) error {
+	var r struct {
+		JSON struct {
+			Errors    [][]string `json:"errors"`
+			Ratelimit float64    `json:"ratelimit"`
+		} `json:"json"`
+	}
+	if json.Unmarshal(body, &r) != nil || len(r.JSON.Errors) == 0 {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: statusCode,
+		RetryAfter: time.Duration(r.JSON.Ratelimit * float64(time.Second)),
+	}
+	fields := r.JSON.Errors[0]
+	if len(fields) > 0 {
+		e.Code = fields[0]
+	}
+	if len(fields) > 1 {
+		e.Message = fields[1]
+	}
+	if len(fields) > 2 {
+		e.Field = fields[2]
+	}
+	return e
+}
+
+// unexpectedError returns an *APIError for a response that reddit reported
+// as successful but whose body is not the one expected. msg describes the
+// failed operation.
+func unexpectedError(statusCode int, body []byte, msg string) error {
+	e := &APIError{StatusCode: statusCode, Message: msg}
+
+	var r struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	if json.Unmarshal(body, &r) == nil && (r.Message != "" || r.Reason != "") {
+		if r.Message != "" {
+			e.Message += ": " + r.Message
+		}
+		e.Code = r.Reason
+	} else if len(body) > 0 {
+		const max = 200
+		if len(body) > max {
+			body = append(body[:max:max], "..."...)
+		}
+		e.Message += ": unexpected response " + strconv.Quote(string(body))
+	}
+	return e
+}
+
+// tokenError converts a failed OAuth token request into an *APIError.
+func tokenError(err error) error {
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.Response == nil {
+		return err
+	}
+
+	e := &APIError{
+		StatusCode: re.Response.StatusCode,
+		Message:    re.Response.Status,
+		RetryAfter: retryAfter(re.Response.Header),
+	}
+	var r struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if json.Unmarshal(re.Body, &r) == nil {
+		e.Code = r.Error
+		if r.Description != "" {
+			e.Message = r.Description
+		}
+	}
+	return e
+}
+
+// retryAfter parses the Retry-After header given in seconds.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
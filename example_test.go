@@ -7,6 +7,7 @@ package geddit_test
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jzelinskie/geddit"
 )
@@ -31,6 +32,29 @@ func ExampleNewOAuthSession_login() {
 	// Ready to make API calls!
 }
 
+func ExampleOAuthSession_LocalCodeAuth() {
+	o, err := geddit.NewOAuthSession(
+		"client_id",
+		"client_secret",
+		"Testing OAuth Bot by u/my_user v0.1 see source https://github.com/jzelinskie/geddit",
+		"http://localhost:8080/callback",
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Serve the redirect URL until the user has authorized the app.
+	err = o.LocalCodeAuth([]string{"identity", "read"}, geddit.PermanentDuration, 5*time.Minute, func(url string) error {
+		fmt.Printf("Visit %s to authorize the app", url)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ready to make API calls!
+}
+
 func ExampleNewOAuthSession_url() {
 	o, err := geddit.NewOAuthSession(
 		"client_id",
@@ -0,0 +1,731 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gedditest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sorts are the listings of submissions of a subreddit or the frontpage.
+var sorts = map[string]bool{
+	"hot":           true,
+	"new":           true,
+	"top":           true,
+	"rising":        true,
+	"controversial": true,
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	// geddit asks for "/r/golang/new.json" on www.reddit.com and for
+	// "/r/golang/new" on oauth.reddit.com.
+	path := strings.TrimSuffix(strings.TrimSuffix(r.URL.Path, ".json"), "/")
+
+	switch {
+	case path == "/api/v1/access_token":
+		s.accessToken(w, r)
+		return
+	case path == "/api/v1/revoke_token":
+		s.revokeToken(w, r)
+		return
+	case path == "/api/login" || strings.HasPrefix(path, "/api/login/"):
+		s.login(w, r)
+		return
+	case path == "/media":
+		s.upload(w, r)
+		return
+	}
+
+	user, ok := s.user(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+	seg := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if r.Method == "POST" {
+		s.post(w, r, user, path)
+		return
+	}
+
+	switch {
+	case path == "" || (len(seg) == 1 && sorts[seg[0]]):
+		s.submissions(w, r, user, "")
+	case seg[0] == "r" && len(seg) == 2:
+		s.submissions(w, r, user, seg[1])
+	case seg[0] == "r" && len(seg) == 3 && sorts[seg[2]]:
+		s.submissions(w, r, user, seg[1])
+	case seg[0] == "r" && len(seg) == 3 && seg[2] == "about":
+		s.aboutSubreddit(w, seg[1])
+	case seg[0] == "r" && len(seg) == 3 && seg[2] == "comments":
+		s.subredditComments(w, r, user, seg[1])
+	case seg[0] == "comments" && len(seg) >= 2:
+		s.thread(w, user, seg[1])
+	case seg[0] == "user" && len(seg) == 3:
+		s.userListing(w, r, user, seg[1], seg[2])
+	case path == "/api/v1/me" || path == "/api/me":
+		if user == "" {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		writeJSON(w, s.redditor(user))
+	case path == "/message/inbox" || path == "/message/unread":
+		if user == "" {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		s.writeListing(w, r, user, s.inbox(user))
+	case path == "/api/morechildren":
+		s.moreChildren(w, user, r.FormValue("children"))
+	case path == "/api/needs_captcha":
+		writeJSON(w, false)
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+// user returns the user a request is made by, which is "" for anonymous
+// and application-only requests. It reports false for invalid credentials.
+func (s *Server) user(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		user, ok := s.tokens[strings.TrimPrefix(auth, "Bearer ")]
+		return user, ok
+	}
+	if c, err := r.Cookie("reddit_session"); err == nil {
+		user, ok := s.cookies[c.Value]
+		return user, ok
+	}
+	return "", true
+}
+
+func (s *Server) accessToken(w http.ResponseWriter, r *http.Request) {
+	var user, refresh string
+	switch r.PostFormValue("grant_type") {
+	case "password":
+		user = r.PostFormValue("username")
+		if password, ok := s.users[user]; ok && password != r.PostFormValue("password") {
+			writeTokenError(w, "invalid_grant")
+			return
+		}
+	case "authorization_code":
+		var ok bool
+		if user, ok = s.codes[r.PostFormValue("code")]; !ok {
+			writeTokenError(w, "invalid_grant")
+			return
+		}
+		delete(s.codes, r.PostFormValue("code"))
+		refresh = "refresh" + s.newID()
+		s.refresh[refresh] = user
+	case "refresh_token":
+		var ok bool
+		refresh = r.PostFormValue("refresh_token")
+		if user, ok = s.refresh[refresh]; !ok {
+			writeTokenError(w, "invalid_grant")
+			return
+		}
+	case "client_credentials", "https://oauth.reddit.com/grants/installed_client":
+	default:
+		writeTokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	token := "token" + s.newID()
+	s.tokens[token] = user
+	resp := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+		"scope":        "*",
+	}
+	if refresh != "" {
+		resp["refresh_token"] = refresh
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request) {
+	delete(s.tokens, r.PostFormValue("token"))
+	delete(s.refresh, r.PostFormValue("token"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	user := r.FormValue("user")
+	if password, ok := s.users[user]; ok && password != r.FormValue("passwd") {
+		writeJSONError(w, "WRONG_PASSWORD", "wrong password", "passwd")
+		return
+	}
+
+	cookie := "cookie" + s.newID()
+	s.cookies[cookie] = user
+	http.SetCookie(w, &http.Cookie{Name: "reddit_session", Value: cookie, Path: "/", HttpOnly: true})
+	writeJSON(w, map[string]interface{}{
+		"json": map[string]interface{}{
+			"errors": []interface{}{},
+			"data":   map[string]string{"modhash": "modhash", "cookie": cookie},
+		},
+	})
+}
+
+func (s *Server) post(w http.ResponseWriter, r *http.Request, user, path string) {
+	if user == "" {
+		writeError(w, http.StatusForbidden)
+		return
+	}
+
+	switch path {
+	case "/api/submit":
+		name, ok := s.subreddits[strings.ToLower(r.FormValue("sr"))]
+		if !ok {
+			writeJSONError(w, "SUBREDDIT_NOEXIST", "that subreddit doesn't exist", "sr")
+			return
+		}
+		var t *thing
+		switch r.FormValue("kind") {
+		case "self":
+			t = s.submit(name, user, r.FormValue("title"), r.FormValue("text"), "")
+		case "image", "video", "videogif":
+			if _, ok := s.uploads[strings.TrimPrefix(r.FormValue("url"), s.URL+"/media/")]; !ok {
+				writeJSONError(w, "BAD_URL", "that URL is not an uploaded file", "url")
+				return
+			}
+			t = s.submit(name, user, r.FormValue("title"), "", r.FormValue("url"))
+		default:
+			t = s.submit(name, user, r.FormValue("title"), "", r.FormValue("url"))
+		}
+		t.nsfw = r.FormValue("nsfw") == "true"
+		t.spoiler = r.FormValue("spoiler") == "true"
+		t.flair = r.FormValue("flair_text")
+		writeJSON(w, map[string]interface{}{
+			"json": map[string]interface{}{
+				"errors": []interface{}{},
+				"data":   map[string]string{"id": t.id, "name": t.fullname(), "url": t.url},
+			},
+		})
+	case "/api/media/asset":
+		id := s.newID()
+		key := "media/" + id + "/" + r.FormValue("filepath")
+		s.assets[id] = key
+		writeJSON(w, map[string]interface{}{
+			"args": map[string]interface{}{
+				"action": s.URL + "/media",
+				"fields": []map[string]string{
+					{"name": "key", "value": key},
+					{"name": "Content-Type", "value": r.FormValue("mimetype")},
+				},
+			},
+			"asset": map[string]string{
+				"asset_id":         id,
+				"processing_state": "incomplete",
+				"websocket_url":    "ws" + strings.TrimPrefix(s.URL, "http") + "/media/" + id,
+			},
+		})
+	case "/api/submit_gallery_post":
+		s.submitGallery(w, r, user)
+	case "/api/submit_poll_post":
+		s.submitPoll(w, r, user)
+	case "/api/comment":
+		c := s.comment(r.FormValue("thing_id"), user, r.FormValue("text"))
+		if c == nil {
+			writeJSONError(w, "NO_THING_ID", "couldn't find that thing", "parent")
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"json": map[string]interface{}{
+				"errors": []interface{}{},
+				"data": map[string]interface{}{
+					"things": []interface{}{s.child(c, user, false)},
+				},
+			},
+		})
+	case "/api/vote":
+		t, ok := s.things[r.FormValue("id")]
+		dir, err := strconv.Atoi(r.FormValue("dir"))
+		if !ok || err != nil || dir < -1 || dir > 1 {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		if dir == 0 {
+			delete(s.votes, userThing{user, t.fullname()})
+		} else {
+			s.votes[userThing{user, t.fullname()}] = dir
+		}
+		writeJSON(w, struct{}{})
+	case "/api/save", "/api/unsave":
+		t, ok := s.things[r.FormValue("id")]
+		if !ok {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		if path == "/api/save" {
+			s.saved[userThing{user, t.fullname()}] = true
+		} else {
+			delete(s.saved, userThing{user, t.fullname()})
+		}
+		writeJSON(w, struct{}{})
+	case "/api/del":
+		t, ok := s.things[r.FormValue("id")]
+		if !ok || t.author != user {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		t.deleted = true
+		writeJSON(w, struct{}{})
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+// upload stores a media file posted with the form leased by
+// /api/media/asset.
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	key := r.FormValue("key")
+	leased := false
+	for _, k := range s.assets {
+		leased = leased || k == key
+	}
+	f, _, err := r.FormFile("file")
+	if !leased || err != nil {
+		writeError(w, http.StatusForbidden)
+		return
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	s.uploads[key] = b
+	w.WriteHeader(http.StatusCreated)
+}
+
+// jsonPost is the JSON body of the gallery and poll submit endpoints.
+type jsonPost struct {
+	Subreddit string `json:"sr"`
+	Title     string `json:"title"`
+	Text      string `json:"text"`
+	NSFW      bool   `json:"nsfw"`
+	Spoiler   bool   `json:"spoiler"`
+	FlairText string `json:"flair_text"`
+	Items     []struct {
+		MediaID string `json:"media_id"`
+	} `json:"items"`
+	Options  []string `json:"options"`
+	Duration int      `json:"duration"`
+}
+
+// submitJSON submits the post of a JSON submit endpoint, once check
+// accepts it.
+func (s *Server) submitJSON(w http.ResponseWriter, r *http.Request, user string, check func(*jsonPost) bool) *thing {
+	var post jsonPost
+	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
+		writeError(w, http.StatusBadRequest)
+		return nil
+	}
+	name, ok := s.subreddits[strings.ToLower(post.Subreddit)]
+	if !ok {
+		writeJSONError(w, "SUBREDDIT_NOEXIST", "that subreddit doesn't exist", "sr")
+		return nil
+	}
+	if !check(&post) {
+		return nil
+	}
+
+	t := s.submit(name, user, post.Title, post.Text, "")
+	t.nsfw, t.spoiler, t.flair = post.NSFW, post.Spoiler, post.FlairText
+	t.poll, t.pollDays = post.Options, post.Duration
+	return t
+}
+
+// writeJSONSubmission answers the JSON submit endpoints, which unlike
+// /api/submit return the fullname as the id.
+func writeJSONSubmission(w http.ResponseWriter, t *thing) {
+	writeJSON(w, map[string]interface{}{
+		"json": map[string]interface{}{
+			"errors": []interface{}{},
+			"data":   map[string]string{"id": t.fullname(), "url": t.url},
+		},
+	})
+}
+
+func (s *Server) submitGallery(w http.ResponseWriter, r *http.Request, user string) {
+	t := s.submitJSON(w, r, user, func(post *jsonPost) bool {
+		if len(post.Items) == 0 {
+			writeJSONError(w, "NO_MEDIA", "a gallery needs images", "items")
+			return false
+		}
+		for _, item := range post.Items {
+			if _, ok := s.uploads[s.assets[item.MediaID]]; !ok {
+				writeJSONError(w, "MEDIA_NOT_UPLOADED", "that media was not uploaded", "items")
+				return false
+			}
+		}
+		return true
+	})
+	if t == nil {
+		return
+	}
+	t.url = "https://www.reddit.com/gallery/" + t.id
+	writeJSONSubmission(w, t)
+}
+
+func (s *Server) submitPoll(w http.ResponseWriter, r *http.Request, user string) {
+	t := s.submitJSON(w, r, user, func(post *jsonPost) bool {
+		if len(post.Options) < 2 || len(post.Options) > 6 {
+			writeJSONError(w, "BAD_NUMBER_OF_OPTIONS", "a poll needs 2 to 6 options", "options")
+			return false
+		}
+		if post.Duration < 1 || post.Duration > 7 {
+			writeJSONError(w, "INVALID_DURATION", "a poll lasts 1 to 7 days", "duration")
+			return false
+		}
+		return true
+	})
+	if t != nil {
+		writeJSONSubmission(w, t)
+	}
+}
+
+// submissions serves the submissions of the given subreddits, joined with
+// "+", or of all subreddits if empty.
+func (s *Server) submissions(w http.ResponseWriter, r *http.Request, user, subreddits string) {
+	in, ok := s.subredditSet(subreddits)
+	if !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	s.writeListing(w, r, user, s.newest(func(t *thing) bool {
+		return t.kind == "t3" && (in == nil || in[strings.ToLower(t.subreddit)])
+	}))
+}
+
+func (s *Server) subredditComments(w http.ResponseWriter, r *http.Request, user, subreddits string) {
+	in, ok := s.subredditSet(subreddits)
+	if !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	s.writeListing(w, r, user, s.newest(func(t *thing) bool {
+		return t.kind == "t1" && in[strings.ToLower(t.subreddit)]
+	}))
+}
+
+// subredditSet returns the lowercase names of the given subreddits, or
+// nil if empty. It reports false if one of them does not exist.
+func (s *Server) subredditSet(subreddits string) (map[string]bool, bool) {
+	if subreddits == "" {
+		return nil, true
+	}
+	in := make(map[string]bool)
+	for _, name := range strings.Split(strings.ToLower(subreddits), "+") {
+		if _, ok := s.subreddits[name]; !ok {
+			return nil, false
+		}
+		in[name] = true
+	}
+	return in, true
+}
+
+func (s *Server) aboutSubreddit(w http.ResponseWriter, name string) {
+	name, ok := s.subreddits[strings.ToLower(name)]
+	if !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"kind": "t5",
+		"data": map[string]interface{}{
+			"display_name": name,
+			"title":        name,
+			"name":         "t5_" + strings.ToLower(name),
+			"id":           strings.ToLower(name),
+			"url":          "/r/" + name + "/",
+			"created_utc":  created,
+		},
+	})
+}
+
+func (s *Server) thread(w http.ResponseWriter, user, id string) {
+	t, ok := s.things["t3_"+id]
+	if !ok {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	var comments []interface{}
+	for _, c := range t.replies {
+		comments = append(comments, s.child(c, user, true))
+	}
+	writeJSON(w, []interface{}{
+		listing([]interface{}{s.child(t, user, false)}, "", ""),
+		listing(comments, "", ""),
+	})
+}
+
+func (s *Server) userListing(w http.ResponseWriter, r *http.Request, user, username, where string) {
+	switch where {
+	case "about":
+		if !s.known(username) {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"kind": "t2", "data": s.redditor(username)})
+	case "submitted", "comments", "overview":
+		kinds := map[string]bool{"t3": where != "comments", "t1": where != "submitted"}
+		s.writeListing(w, r, user, s.newest(func(t *thing) bool {
+			return kinds[t.kind] && t.author == username && !t.deleted
+		}))
+	case "saved":
+		if user != username {
+			writeError(w, http.StatusForbidden)
+			return
+		}
+		s.writeListing(w, r, user, s.newest(func(t *thing) bool {
+			return s.saved[userThing{user, t.fullname()}]
+		}))
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func (s *Server) moreChildren(w http.ResponseWriter, user, children string) {
+	things := []interface{}{}
+	for _, id := range strings.Split(children, ",") {
+		if c, ok := s.things["t1_"+id]; ok {
+			things = append(things, s.child(c, user, false))
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"json": map[string]interface{}{
+			"errors": []interface{}{},
+			"data":   map[string]interface{}{"things": things},
+		},
+	})
+}
+
+// known reports whether username is registered or authored a thing.
+func (s *Server) known(username string) bool {
+	if _, ok := s.users[username]; ok {
+		return true
+	}
+	for _, t := range s.order {
+		if t.author == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) redditor(username string) map[string]interface{} {
+	var link, comment int
+	for _, t := range s.order {
+		if t.author != username || t.deleted {
+			continue
+		}
+		switch t.kind {
+		case "t3":
+			link += s.score(t)
+		case "t1":
+			comment += s.score(t)
+		}
+	}
+	return map[string]interface{}{
+		"name":          username,
+		"id":            strings.ToLower(username),
+		"created_utc":   created,
+		"link_karma":    link,
+		"comment_karma": comment,
+		"has_mail":      len(s.inbox(username)) > 0,
+	}
+}
+
+// newest returns the things matching keep, newest first.
+func (s *Server) newest(keep func(*thing) bool) []*thing {
+	var things []*thing
+	for i := len(s.order) - 1; i >= 0; i-- {
+		if keep(s.order[i]) {
+			things = append(things, s.order[i])
+		}
+	}
+	return things
+}
+
+// writeListing writes the page of things selected by the limit, after and
+// before parameters of r.
+func (s *Server) writeListing(w http.ResponseWriter, r *http.Request, user string, things []*thing) {
+	limit := 25
+	if n, err := strconv.Atoi(r.FormValue("limit")); err == nil && n > 0 {
+		limit = min(n, 100)
+	}
+	index := func(fullname string) int {
+		for i, t := range things {
+			if t.fullname() == fullname {
+				return i
+			}
+		}
+		return -1
+	}
+
+	start, end := 0, len(things)
+	if after := r.FormValue("after"); after != "" {
+		start = len(things)
+		if i := index(after); i >= 0 {
+			start = i + 1
+		}
+	} else if before := r.FormValue("before"); before != "" {
+		end = max(index(before), 0)
+		start = max(end-limit, 0)
+	}
+	end = min(end, start+limit)
+
+	page := things[start:end]
+	children := []interface{}{}
+	for _, t := range page {
+		children = append(children, s.child(t, user, false))
+	}
+	var after, before string
+	if len(page) > 0 && end < len(things) {
+		after = page[len(page)-1].fullname()
+	}
+	if len(page) > 0 && start > 0 {
+		before = page[0].fullname()
+	}
+	writeJSON(w, listing(children, after, before))
+}
+
+// child returns the listing child of t as seen by user, with its tree of
+// replies if replies is set.
+func (s *Server) child(t *thing, user string, replies bool) map[string]interface{} {
+	d := map[string]interface{}{
+		"id":          t.id,
+		"name":        t.fullname(),
+		"author":      t.author,
+		"created_utc": t.created,
+	}
+	body := t.body
+	if t.deleted {
+		d["author"], body = "[deleted]", "[deleted]"
+	}
+
+	switch t.kind {
+	case "t3":
+		d["title"] = t.title
+		d["url"] = t.url
+		d["selftext"] = body
+		d["is_self"] = t.url == s.URL+permalink(t)
+		d["domain"] = "self." + t.subreddit
+		d["num_comments"] = len(s.newest(func(c *thing) bool { return c.kind == "t1" && c.link == t.fullname() }))
+		d["over_18"] = t.nsfw
+		d["spoiler"] = t.spoiler
+		d["link_flair_text"] = t.flair
+		if len(t.poll) > 0 {
+			var options []interface{}
+			for i, o := range t.poll {
+				options = append(options, map[string]string{"id": strconv.Itoa(i + 1), "text": o})
+			}
+			d["poll_data"] = map[string]interface{}{
+				"options":              options,
+				"total_vote_count":     0,
+				"voting_end_timestamp": (t.created + int64(t.pollDays)*24*60*60) * 1000,
+			}
+		}
+	case "t1":
+		d["body"] = body
+		d["link_id"] = t.link
+		d["link_title"] = t.title
+		d["parent_id"] = t.parent
+		d["replies"] = ""
+		if replies && len(t.replies) > 0 {
+			var children []interface{}
+			for _, c := range t.replies {
+				children = append(children, s.child(c, user, true))
+			}
+			d["replies"] = listing(children, "", "")
+		}
+	case "t4":
+		d["dest"] = t.dest
+		d["subject"] = t.title
+		d["body"] = body
+		d["was_comment"] = false
+		return map[string]interface{}{"kind": t.kind, "data": d}
+	}
+
+	d["subreddit"] = t.subreddit
+	d["subreddit_id"] = "t5_" + strings.ToLower(t.subreddit)
+	d["permalink"] = permalink(t)
+	d["score"] = s.score(t)
+	d["ups"] = s.score(t)
+	d["downs"] = 0
+	d["saved"] = s.saved[userThing{user, t.fullname()}]
+	switch s.votes[userThing{user, t.fullname()}] {
+	case 1:
+		d["likes"] = true
+	case -1:
+		d["likes"] = false
+	default:
+		d["likes"] = nil
+	}
+	return map[string]interface{}{"kind": t.kind, "data": d}
+}
+
+func listing(children []interface{}, after, before string) map[string]interface{} {
+	if children == nil {
+		children = []interface{}{}
+	}
+	data := map[string]interface{}{"children": children, "after": nil, "before": nil}
+	if after != "" {
+		data["after"] = after
+	}
+	if before != "" {
+		data["before"] = before
+	}
+	return map[string]interface{}{"kind": "Listing", "data": data}
+}
+
+// writeJSON writes v without a trailing newline, as reddit does.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	b, _ := json.Marshal(map[string]interface{}{"message": http.StatusText(code), "error": code})
+	w.Write(b)
+}
+
+// writeJSONError writes an error of an api_type=json response.
+func writeJSONError(w http.ResponseWriter, code, msg, field string) {
+	writeJSON(w, map[string]interface{}{
+		"json": map[string]interface{}{
+			"errors": [][]string{{code, msg, field}},
+		},
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusBadRequest)
+	b, _ := json.Marshal(map[string]string{"error": code})
+	w.Write(b)
+}
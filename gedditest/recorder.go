@@ -0,0 +1,304 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gedditest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// Replay serves the interactions of a cassette file without touching
+	// the network.
+	Replay Mode = iota
+	// Record sends requests to reddit and captures the interactions, to be
+	// written to a cassette file by Save.
+	Record
+)
+
+// redacted replaces the secrets scrubbed from recorded interactions.
+const redacted = "REDACTED"
+
+// secretParams are the query and form parameters scrubbed from recorded
+// requests.
+var secretParams = []string{
+	"passwd", "password", "curpass", "uh", "code", "token", "refresh_token",
+	"client_secret", "device_id",
+}
+
+// secretFields are the JSON fields scrubbed from recorded responses.
+var secretFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"modhash":       true,
+	"cookie":        true,
+}
+
+// Interaction is a request and the response reddit answered it with.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the recorded part of a request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the recorded part of a response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording the interactions of a geddit
+// session with reddit to a cassette file, or replaying them. Passwords,
+// tokens, cookies and modhashes are scrubbed from the recorded interactions.
+// Use it through WithHTTPClient:
+//
+//	rec, err := gedditest.NewRecorder("testdata/frontpage.json", gedditest.Replay, nil)
+//	s := geddit.NewSession("test agent", geddit.WithHTTPClient(rec.Client()))
+//
+// In Replay mode, each request is answered by the first unused interaction
+// with the same method and URL, ignoring scrubbed parameters.
+type Recorder struct {
+	// Scrub, if set, is called on every interaction before it is recorded,
+	// to remove further data.
+	Scrub func(*Interaction)
+
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns a Recorder of the cassette file at path. In Record
+// mode requests are sent through transport, or http.DefaultTransport if
+// nil. In Replay mode the cassette file must exist.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, transport: transport}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+	if mode == Record {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, fmt.Errorf("gedditest: invalid cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an HTTP client using the Recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	i := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req.URL),
+			Header: req.Header.Clone(),
+			Body:   scrubForm(string(body)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       scrubJSON(respBody),
+		},
+	}
+	for _, h := range []string{"Authorization", "Cookie"} {
+		if i.Request.Header.Get(h) != "" {
+			i.Request.Header.Set(h, redacted)
+		}
+	}
+	for n, c := range i.Response.Header.Values("Set-Cookie") {
+		i.Response.Header["Set-Cookie"][n] = scrubSetCookie(c)
+	}
+	if r.Scrub != nil {
+		r.Scrub(&i)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	u := scrubURL(req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n, i := range r.cassette.Interactions {
+		if r.used[n] || i.Request.Method != req.Method || i.Request.URL != u {
+			continue
+		}
+		r.used[n] = true
+
+		header := i.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+			StatusCode:    i.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("gedditest: no recorded interaction for %s %s", req.Method, u)
+}
+
+// Save writes the recorded interactions to the cassette file. It does
+// nothing in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+func scrubURL(u *url.URL) string {
+	c := *u
+	c.RawQuery = scrubForm(u.RawQuery)
+	return c.String()
+}
+
+// scrubSetCookie redacts the value of a Set-Cookie header, keeping the
+// name and attributes of the cookie for replayed sessions to use it.
+func scrubSetCookie(c string) string {
+	name, rest, ok := strings.Cut(c, "=")
+	if !ok {
+		return redacted
+	}
+	_, attrs, _ := strings.Cut(rest, ";")
+	if attrs == "" {
+		return name + "=" + redacted
+	}
+	return name + "=" + redacted + ";" + attrs
+}
+
+// scrubForm redacts the secret parameters of an URL encoded form.
+func scrubForm(form string) string {
+	v, err := url.ParseQuery(form)
+	if err != nil || form == "" {
+		return form
+	}
+	for _, p := range secretParams {
+		if _, ok := v[p]; ok {
+			v.Set(p, redacted)
+		}
+	}
+	return v.Encode()
+}
+
+// scrubJSON redacts the secret fields of a JSON body, which is returned
+// as is if it is not JSON.
+func scrubJSON(body []byte) string {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil || !scrubValue(v) {
+		return string(body)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+// scrubValue redacts the secret fields of v and reports whether any was
+// found.
+func scrubValue(v interface{}) bool {
+	var found bool
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if _, ok := e.(string); ok && secretFields[k] {
+				v[k] = redacted
+				found = true
+				continue
+			}
+			found = scrubValue(e) || found
+		}
+	case []interface{}:
+		for _, e := range v {
+			found = scrubValue(e) || found
+		}
+	}
+	return found
+}
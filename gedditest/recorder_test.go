@@ -0,0 +1,77 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gedditest_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jzelinskie/geddit"
+	"github.com/jzelinskie/geddit/gedditest"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := gedditest.NewServer()
+	srv.AddUser("gopher", "hunter2")
+	post := srv.AddSubmission("golang", "gopher", "title", "text")
+	srv.AddComment(post, "rob", "comment")
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	run := func(rec *gedditest.Recorder) (*geddit.Redditor, *geddit.Thread) {
+		s, err := geddit.NewLoginSession("gopher", "hunter2", "test", geddit.WithBaseURL(srv.URL), geddit.WithHTTPClient(rec.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := s.AboutRedditor("gopher")
+		if err != nil {
+			t.Fatal(err)
+		}
+		thread, err := s.Thread(strings.TrimPrefix(post, "t3_"), geddit.DefaultPopularity, geddit.ListingOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, thread
+	}
+
+	rec, err := gedditest.NewRecorder(cassette, gedditest.Record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordedRedditor, recordedThread := run(rec)
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	b, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "reddit_session=cookie", `"modhash":"modhash"`} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+	if !strings.Contains(string(b), "reddit_session=REDACTED; Path=/; HttpOnly") {
+		t.Errorf("cassette lacks the redacted session cookie: %s", b)
+	}
+
+	rec, err = gedditest.NewRecorder(cassette, gedditest.Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, thread := run(rec)
+	if !reflect.DeepEqual(r, recordedRedditor) || !reflect.DeepEqual(thread, recordedThread) {
+		t.Fatal("replayed interactions differ from the recorded ones")
+	}
+
+	s := geddit.NewSession("test", geddit.WithBaseURL(srv.URL), geddit.WithHTTPClient(rec.Client()))
+	if _, err := s.AboutSubreddit("golang"); err == nil {
+		t.Fatal("a request missing from the cassette did not fail")
+	}
+}
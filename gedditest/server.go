@@ -0,0 +1,347 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gedditest implements a fake reddit API for testing code using
+// geddit without touching the network.
+//
+// A Server keeps subreddits, submissions, comments, votes, saves and
+// private messages in memory and serves them through the endpoints geddit
+// uses, both the www.reddit.com ones and the OAuth ones:
+//
+//	srv := gedditest.NewServer()
+//	defer srv.Close()
+//	srv.AddSubreddit("golang")
+//	srv.AddSubmission("golang", "gopher", "Hello", "world")
+//
+//	s := geddit.NewSession("test agent", srv.Options()...)
+//	submissions, err := s.SubredditSubmissions("golang", geddit.NewSubmissions, geddit.ListingOptions{})
+package gedditest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jzelinskie/geddit"
+)
+
+// created is the creation time of the first thing of a Server. Every
+// following thing is created one second later than the previous one.
+const created = 1500000000
+
+// Message is a private message or reply notification in the inbox of a
+// user.
+type Message struct {
+	FullID  string
+	Author  string
+	Dest    string
+	Subject string
+	Body    string
+	// Context is the fullname of the reply a notification is about, if
+	// any.
+	Context string
+}
+
+// thing is a submission, comment or message.
+type thing struct {
+	kind      string
+	id        string
+	subreddit string
+	author    string
+	title     string
+	body      string
+	url       string
+	parent    string
+	link      string
+	dest      string
+	created   int64
+	deleted   bool
+	replies   []*thing
+	nsfw      bool
+	spoiler   bool
+	flair     string
+	poll      []string
+	pollDays  int
+}
+
+func (t *thing) fullname() string {
+	return t.kind + "_" + t.id
+}
+
+type userThing struct {
+	user     string
+	fullname string
+}
+
+// Server is a fake reddit API served by an httptest.Server. It is safe
+// for concurrent use.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	next       int64
+	users      map[string]string
+	tokens     map[string]string
+	refresh    map[string]string
+	codes      map[string]string
+	cookies    map[string]string
+	subreddits map[string]string
+	things     map[string]*thing
+	order      []*thing
+	votes      map[userThing]int
+	saved      map[userThing]bool
+	assets     map[string]string
+	uploads    map[string][]byte
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		users:      make(map[string]string),
+		tokens:     make(map[string]string),
+		refresh:    make(map[string]string),
+		codes:      make(map[string]string),
+		cookies:    make(map[string]string),
+		subreddits: make(map[string]string),
+		things:     make(map[string]*thing),
+		votes:      make(map[userThing]int),
+		saved:      make(map[userThing]bool),
+		assets:     make(map[string]string),
+		uploads:    make(map[string][]byte),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Options returns the geddit options pointing a session at the server.
+func (s *Server) Options() []geddit.Option {
+	return []geddit.Option{
+		geddit.WithBaseURL(s.URL),
+		geddit.WithOAuthURL(s.URL),
+		geddit.WithAuthURL(s.URL),
+		geddit.WithHTTPClient(s.srv.Client()),
+	}
+}
+
+// AddUser registers an account. Password logins of registered accounts
+// must use the given password, other usernames log in with any password.
+func (s *Server) AddUser(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = password
+}
+
+// AddSubreddit creates a subreddit.
+func (s *Server) AddSubreddit(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subreddits[strings.ToLower(name)] = name
+}
+
+// AddSubmission creates a self post and returns its fullname. The
+// subreddit is created if needed.
+func (s *Server) AddSubmission(subreddit, author, title, text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subreddits[strings.ToLower(subreddit)]; !ok {
+		s.subreddits[strings.ToLower(subreddit)] = subreddit
+	}
+	return s.submit(subreddit, author, title, text, "").fullname()
+}
+
+// AddComment creates a comment replying to the submission or comment with
+// the given fullname and returns its fullname, or "" if the parent does not
+// exist.
+func (s *Server) AddComment(parent, author, body string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.comment(parent, author, body)
+	if c == nil {
+		return ""
+	}
+	return c.fullname()
+}
+
+// AddMessage sends a private message and returns its fullname.
+func (s *Server) AddMessage(from, to, subject, body string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.add(&thing{kind: "t4", author: from, dest: to, title: subject, body: body})
+	return m.fullname()
+}
+
+// AuthorizeCode returns an authorization code, as reddit passes to the
+// redirect URL, which geddit's CodeAuth exchanges for a token of username.
+func (s *Server) AuthorizeCode(username string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := "code" + s.newID()
+	s.codes[code] = username
+	return code
+}
+
+// Vote returns the vote of username on the given thing: 1, 0 or -1.
+func (s *Server) Vote(username, fullname string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.votes[userThing{username, fullname}]
+}
+
+// Saved reports whether username saved the given thing.
+func (s *Server) Saved(username, fullname string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saved[userThing{username, fullname}]
+}
+
+// Deleted reports whether the given thing was deleted.
+func (s *Server) Deleted(fullname string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.things[fullname]
+	return ok && t.deleted
+}
+
+// Upload returns the content of the media file uploaded at the given URL,
+// as used by image and video submissions.
+func (s *Server) Upload(url string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.uploads[strings.TrimPrefix(url, s.URL+"/media/")]
+	return b, ok
+}
+
+// Inbox returns the messages and reply notifications sent to username,
+// newest first.
+func (s *Server) Inbox(username string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var inbox []Message
+	for _, t := range s.inbox(username) {
+		m := Message{
+			FullID:  t.fullname(),
+			Author:  t.author,
+			Dest:    username,
+			Subject: t.title,
+			Body:    t.body,
+		}
+		if t.kind == "t1" {
+			m.Subject = "post reply"
+			if t.parent != t.link {
+				m.Subject = "comment reply"
+			}
+			m.Context = t.fullname()
+		}
+		inbox = append(inbox, m)
+	}
+	return inbox
+}
+
+// newID returns a new base 36 ID.
+func (s *Server) newID() string {
+	s.next++
+	return strconv.FormatInt(s.next, 36)
+}
+
+// add stores t with a new ID.
+func (s *Server) add(t *thing) *thing {
+	t.id = s.newID()
+	t.created = created + s.next
+	s.things[t.fullname()] = t
+	s.order = append(s.order, t)
+	return t
+}
+
+func (s *Server) submit(subreddit, author, title, text, link string) *thing {
+	t := &thing{
+		kind:      "t3",
+		subreddit: s.subreddits[strings.ToLower(subreddit)],
+		author:    author,
+		title:     title,
+		body:      text,
+		url:       link,
+	}
+	s.add(t)
+	if link == "" {
+		t.url = s.URL + permalink(t)
+	}
+	return t
+}
+
+// comment adds a reply to parent, notifying the author of parent.
+func (s *Server) comment(parent, author, body string) *thing {
+	p, ok := s.things[parent]
+	if !ok || p.kind == "t4" {
+		return nil
+	}
+	link := parent
+	if p.kind == "t1" {
+		link = p.link
+	}
+	c := s.add(&thing{
+		kind:      "t1",
+		subreddit: p.subreddit,
+		author:    author,
+		body:      body,
+		parent:    parent,
+		link:      link,
+		title:     s.things[link].title,
+	})
+	p.replies = append(p.replies, c)
+	return c
+}
+
+// inbox returns the private messages to username and the replies to the
+// things of username, newest first.
+func (s *Server) inbox(username string) []*thing {
+	var inbox []*thing
+	for i := len(s.order) - 1; i >= 0; i-- {
+		t := s.order[i]
+		switch t.kind {
+		case "t4":
+			if t.dest == username {
+				inbox = append(inbox, t)
+			}
+		case "t1":
+			if p := s.things[t.parent]; p.author == username && t.author != username {
+				inbox = append(inbox, t)
+			}
+		}
+	}
+	return inbox
+}
+
+// score returns the score of t, which counts the implicit upvote of its
+// author.
+func (s *Server) score(t *thing) int {
+	score := 1
+	for k, v := range s.votes {
+		if k.fullname == t.fullname() {
+			score += v
+		}
+	}
+	return score
+}
+
+func permalink(t *thing) string {
+	switch t.kind {
+	case "t3":
+		return "/r/" + t.subreddit + "/comments/" + t.id + "/"
+	case "t1":
+		return "/r/" + t.subreddit + "/comments/" + strings.TrimPrefix(t.link, "t3_") + "/_/" + t.id + "/"
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gedditest_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jzelinskie/geddit"
+	"github.com/jzelinskie/geddit/gedditest"
+)
+
+func TestSession(t *testing.T) {
+	srv := gedditest.NewServer()
+	defer srv.Close()
+
+	first := srv.AddSubmission("golang", "gopher", "first", "text")
+	for i := 0; i < 4; i++ {
+		srv.AddSubmission("golang", "gopher", "more", "text")
+	}
+	top := srv.AddComment(first, "rob", "top")
+	srv.AddComment(top, "ken", "reply")
+
+	s := geddit.NewSession("test", srv.Options()...)
+	it := s.SubredditSubmissionsIterator("golang", geddit.NewSubmissions, geddit.ListingOptions{Limit: 2})
+	var n int
+	for it.Next(t.Context()) {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("iterated over %d submissions, want 5", n)
+	}
+
+	thread, err := s.Thread(first[3:], geddit.DefaultPopularity, geddit.ListingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Submission.Title != "first" || len(thread.Comments) != 1 || len(thread.Comments[0].Replies) != 1 {
+		t.Fatalf("unexpected thread: %+v", thread)
+	}
+
+	if _, err := s.AboutSubreddit("rust"); err == nil {
+		t.Fatal("AboutSubreddit() of an unknown subreddit did not fail")
+	}
+}
+
+func TestLoginSession(t *testing.T) {
+	srv := gedditest.NewServer()
+	defer srv.Close()
+	srv.AddUser("gopher", "hunter2")
+	srv.AddSubreddit("golang")
+
+	if _, err := geddit.NewLoginSession("gopher", "wrong", "test", srv.Options()...); err == nil {
+		t.Fatal("NewLoginSession() with a wrong password did not fail")
+	}
+	s, err := geddit.NewLoginSession("gopher", "hunter2", "test", srv.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := s.Submit(geddit.NewTextSubmission("golang", "title", "text", true, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Vote(sub, geddit.DownVote); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Vote("gopher", sub.FullID) != -1 {
+		t.Fatal("Vote() was not recorded")
+	}
+	if err := s.Delete(sub); err != nil {
+		t.Fatal(err)
+	}
+	if !srv.Deleted(sub.FullID) {
+		t.Fatal("Delete() was not recorded")
+	}
+}
+
+func TestOAuthSession(t *testing.T) {
+	srv := gedditest.NewServer()
+	defer srv.Close()
+	post := srv.AddSubmission("golang", "rob", "title", "text")
+
+	o, err := geddit.NewOAuthSession("id", "secret", "test", "http://localhost/callback", srv.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.CodeAuth(srv.AuthorizeCode("gopher")); err != nil {
+		t.Fatal(err)
+	}
+
+	me, err := o.Me()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Name != "gopher" {
+		t.Fatalf("Me() returned %s, want gopher", me.Name)
+	}
+
+	sub := &geddit.Submission{FullID: post}
+	c, err := o.Reply(sub, "nice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Body != "nice" || c.ParentID != post {
+		t.Fatalf("Reply() returned unexpected comment: %+v", c)
+	}
+	if inbox := srv.Inbox("rob"); len(inbox) != 1 || inbox[0].Context != c.FullID {
+		t.Fatalf("unexpected inbox: %+v", inbox)
+	}
+
+	if err := o.Save(sub, ""); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := o.MySavedLinks(geddit.ListingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].FullID != post || !srv.Saved("gopher", post) {
+		t.Fatalf("unexpected saved links: %v", saved)
+	}
+
+	if err := o.Revoke(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMediaSubmission(t *testing.T) {
+	srv := gedditest.NewServer()
+	defer srv.Close()
+	srv.AddSubreddit("golang")
+
+	o, err := geddit.NewOAuthSession("id", "secret", "test", "http://localhost/callback", srv.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.CodeAuth(srv.AuthorizeCode("gopher")); err != nil {
+		t.Fatal(err)
+	}
+
+	image := &geddit.Upload{MIMEType: "image/png", Body: strings.NewReader("png")}
+	sub, err := o.Submit(geddit.NewImageSubmission("golang", "gopher", image, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := srv.Upload(sub.URL); !ok || string(b) != "png" {
+		t.Fatalf("unexpected upload at %s: %q", sub.URL, b)
+	}
+
+	// A body of unknown length is streamed too.
+	gif := &geddit.Upload{MIMEType: "image/gif", Body: io.MultiReader(strings.NewReader("gif"))}
+	asset, err := o.UploadMedia(gif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := srv.Upload(asset.URL); !ok || string(b) != "gif" {
+		t.Fatalf("unexpected upload at %s: %q", asset.URL, b)
+	}
+
+	video := &geddit.Upload{Name: "gopher.mp4", MIMEType: "video/mp4", Body: strings.NewReader("mp4")}
+	if _, err := o.Submit(geddit.NewVideoSubmission("golang", "gopher", video, nil, true)); err == nil {
+		t.Fatal("Submit() of a video without poster did not fail")
+	}
+
+	gallery := geddit.NewGallerySubmission("golang", "gophers", []*geddit.GalleryImage{
+		{Image: &geddit.Upload{MIMEType: "image/jpeg", Body: strings.NewReader("a")}, Caption: "a"},
+		{Image: &geddit.Upload{MIMEType: "image/jpeg", Body: strings.NewReader("b")}, OutboundURL: "https://go.dev"},
+	}, true)
+	if sub, err = o.Submit(gallery); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sub.URL, "/gallery/") {
+		t.Fatalf("unexpected gallery submission: %+v", sub)
+	}
+}
+
+func TestPollSubmission(t *testing.T) {
+	srv := gedditest.NewServer()
+	defer srv.Close()
+	srv.AddSubreddit("golang")
+
+	o, err := geddit.NewOAuthSession("id", "secret", "test", "http://localhost/callback", srv.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.CodeAuth(srv.AuthorizeCode("gopher")); err != nil {
+		t.Fatal(err)
+	}
+
+	ns := geddit.NewPollSubmission("golang", "Generics?", "Vote", []string{"yes", "no"}, 3, true)
+	ns.Spoiler, ns.FlairText, ns.Save = true, "poll", true
+	sub, err := o.Submit(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID == "" || sub.FullID != "t3_"+sub.ID || !srv.Saved("gopher", sub.FullID) {
+		t.Fatalf("unexpected submission: %+v", sub)
+	}
+
+	thread, err := o.Thread(sub.ID, geddit.DefaultPopularity, geddit.ListingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := thread.Submission
+	if !got.IsSpoiler || got.LinkFlairText != "poll" || got.PollData == nil || len(got.PollData.Options) != 2 {
+		t.Fatalf("unexpected poll: %+v", got)
+	}
+
+	if _, err := o.Submit(geddit.NewPollSubmission("golang", "Poll", "", []string{"only"}, 3, true)); err == nil {
+		t.Fatal("Submit() of a poll with one option did not fail")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// listing is the JSON representation of a reddit Listing.
+type listing struct {
+	Data struct {
+		After    string
+		Before   string
+		Children []Thing
+	}
+}
+
+// things returns the things of the listing.
+func (l *listing) things() ([]Thing, error) {
+	return l.Data.Children, nil
+}
+
+func (l *listing) submissions() ([]*Submission, error) {
+	return thingsOf[*Submission](l.Data.Children), nil
+}
+
+func (l *listing) comments() ([]*Comment, error) {
+	return thingsOf[*Comment](l.Data.Children), nil
+}
+
+func (l *listing) moreComments() ([]*MoreComments, error) {
+	return thingsOf[*MoreComments](l.Data.Children), nil
+}
+
+// newThread builds a Thread from the listing of the submission and the
+// listing of its comments returned by /comments/{id}.
+func newThread(ls []listing) (*Thread, error) {
+	if len(ls) != 2 {
+		return nil, unexpectedError(http.StatusOK, nil, fmt.Sprintf("unexpected comments response of %d listings", len(ls)))
+	}
+
+	submissions, err := ls[0].submissions()
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) == 0 {
+		return nil, unexpectedError(http.StatusOK, nil, "comments response lacks a submission")
+	}
+
+	t := &Thread{Submission: submissions[0]}
+	if t.Comments, err = ls[1].comments(); err != nil {
+		return nil, err
+	}
+	if t.More, err = ls[1].moreComments(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (l *listing) subreddits() ([]*Subreddit, error) {
+	return thingsOf[*Subreddit](l.Data.Children), nil
+}
+
+// thingsOf returns the data of the things of type T, skipping the others.
+func thingsOf[T any](things []Thing) []T {
+	var data []T
+	for _, t := range things {
+		if d, ok := t.Data.(T); ok {
+			data = append(data, d)
+		}
+	}
+	return data
+}
+
+// ListingIterator iterates over the items of a reddit Listing, fetching
+// one page at a time and keeping track of the after, before and count
+// parameters. It is used like so:
+//
+//	it := session.SubredditSubmissionsIterator("golang", geddit.NewSubmissions, geddit.ListingOptions{})
+//	it.SetMax(500)
+//	for it.Next(ctx) {
+//		fmt.Println(it.Item())
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+//
+// Iteration follows the before cursor when the initial ListingOptions only
+// set Before, and the after cursor otherwise.
+type ListingIterator[T any] struct {
+	fetch    func(context.Context, ListingOptions) (*listing, error)
+	items    func(*listing) ([]T, error)
+	params   ListingOptions
+	backward bool
+	max      int
+	count    int
+	started  bool
+	done     bool
+	page     []T
+	item     T
+	after    string
+	before   string
+	err      error
+}
+
+func newListingIterator[T any](params ListingOptions, fetch func(context.Context, ListingOptions) (*listing, error), items func(*listing) ([]T, error)) *ListingIterator[T] {
+	return &ListingIterator[T]{
+		fetch:    fetch,
+		items:    items,
+		params:   params,
+		backward: params.Before != "" && params.After == "",
+		count:    params.Count,
+	}
+}
+
+// SetMax stops the iteration after n items. Zero means no limit.
+func (it *ListingIterator[T]) SetMax(n int) {
+	it.max = n
+}
+
+// Next advances the iterator to the next item, fetching the next page
+// when needed. It returns false once the listing is exhausted, the maximum
+// number of items has been reached or an error occurred.
+func (it *ListingIterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil || (it.max > 0 && it.count-it.params.Count >= it.max) {
+		return false
+	}
+
+	for len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.fetchPage(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.item, it.page = it.page[0], it.page[1:]
+	it.count++
+	return true
+}
+
+func (it *ListingIterator[T]) fetchPage(ctx context.Context) error {
+	params := it.params
+	if it.started {
+		params.Count = it.count
+		if it.backward {
+			params.After, params.Before = "", it.before
+		} else {
+			params.After, params.Before = it.after, ""
+		}
+	}
+	if it.max > 0 {
+		if left := it.max - (it.count - it.params.Count); params.Limit == 0 || left < params.Limit {
+			params.Limit = left
+		}
+	}
+
+	l, err := it.fetch(ctx, params)
+	if err != nil {
+		return err
+	}
+	page, err := it.items(l)
+	if err != nil {
+		return err
+	}
+
+	it.started = true
+	it.page = page
+	it.after, it.before = l.Data.After, l.Data.Before
+	if len(l.Data.Children) == 0 ||
+		(it.backward && it.before == "") ||
+		(!it.backward && it.after == "") {
+		it.done = true
+	}
+	return nil
+}
+
+// Item returns the current item.
+func (it *ListingIterator[T]) Item() T {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ListingIterator[T]) Err() error {
+	return it.err
+}
+
+// After returns the after cursor of the last fetched page.
+func (it *ListingIterator[T]) After() string {
+	return it.after
+}
+
+// Before returns the before cursor of the last fetched page.
+func (it *ListingIterator[T]) Before() string {
+	return it.before
+}
+
+// Count returns the number of items iterated over, including the initial
+// Count of the ListingOptions.
+func (it *ListingIterator[T]) Count() int {
+	return it.count
+}
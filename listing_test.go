@@ -0,0 +1,61 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListingIterator(t *testing.T) {
+	pages := map[string]string{
+		"":     `{"data": {"after": "t3_b", "children": [{"kind": "t3", "data": {"name": "t3_a"}}, {"kind": "t3", "data": {"name": "t3_b"}}]}}`,
+		"t3_b": `{"data": {"after": "t3_c", "before": "t3_c", "children": [{"kind": "t3", "data": {"name": "t3_c"}}]}}`,
+		"t3_c": `{"data": {"after": null, "before": "t3_d", "children": [{"kind": "t3", "data": {"name": "t3_d"}}]}}`,
+	}
+	var counts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/r/golang/new.json" {
+			t.Errorf("unexpected request path: %s", r.URL.Path)
+		}
+		counts = append(counts, r.URL.Query().Get("count"))
+		fmt.Fprintln(w, pages[r.URL.Query().Get("after")])
+	}))
+	defer server.Close()
+
+	s := NewSession("Geddit Test", WithBaseURL(server.URL))
+	it := s.SubredditSubmissionsIterator("golang", NewSubmissions, ListingOptions{})
+
+	var names []string
+	for it.Next(context.Background()) {
+		names = append(names, it.Item().FullID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[t3_a t3_b t3_c t3_d]" {
+		t.Fatalf("unexpected items: %v", names)
+	}
+	if fmt.Sprint(counts) != "[ 2 3]" {
+		t.Fatalf("unexpected count parameters: %q", counts)
+	}
+	if it.Count() != 4 || it.Before() != "t3_d" {
+		t.Fatalf("unexpected iterator state: count %d, before %q", it.Count(), it.Before())
+	}
+
+	// A maximum stops the iteration early.
+	it = s.SubredditSubmissionsIterator("golang", NewSubmissions, ListingOptions{})
+	it.SetMax(3)
+	n := 0
+	for it.Next(context.Background()) {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("SetMax(3) yielded %d items", n)
+	}
+}
@@ -6,6 +6,7 @@
 package geddit
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,42 +25,49 @@ type LoginSession struct {
 	password  string
 	useragent string
 	cookie    *http.Cookie
-	modhash   string `json:"modhash"`
+	modhash   string
 	Session
 }
 
 // NewLoginSession creates a new session for those who want to log into a
 // reddit account.
-func NewLoginSession(username, password, useragent string) (*LoginSession, error) {
+func NewLoginSession(username, password, useragent string, opts ...Option) (*LoginSession, error) {
+	return NewLoginSessionContext(context.Background(), username, password, useragent, opts...)
+}
+
+// NewLoginSessionContext is like NewLoginSession but with a context.
+func NewLoginSessionContext(ctx context.Context, username, password, useragent string, opts ...Option) (*LoginSession, error) {
 	session := &LoginSession{
 		username:  username,
 		password:  password,
 		useragent: useragent,
-		Session:   Session{useragent},
+		Session:   Session{useragent: useragent, opts: newOptions(opts)},
 	}
 
-	loginURL := fmt.Sprintf("https://www.reddit.com/api/login/%s", username)
+	passwd, err := session.opts.password(password)
+	if err != nil {
+		return nil, err
+	}
+	loginURL := fmt.Sprintf("%s/api/login/%s", session.opts.wwwURL(), username)
 	postValues := url.Values{
 		"user":     {username},
-		"passwd":   {password},
+		"passwd":   {passwd},
 		"api_type": {"json"},
 	}
 
-	req, err := http.NewRequest("POST", loginURL, strings.NewReader(postValues.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", loginURL, strings.NewReader(postValues.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", useragent)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, body, err := session.opts.do(session.opts.httpClient(), req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+	if err := jsonError(resp.StatusCode, body); err != nil {
+		return nil, session.opts.otpError(err)
 	}
 
 	// Get the session cookie.
@@ -72,26 +80,18 @@ func NewLoginSession(username, password, useragent string) (*LoginSession, error
 	// Get the modhash from the JSON.
 	type Response struct {
 		JSON struct {
-			Errors [][]string
-			Data   struct {
+			Data struct {
 				Modhash string
 			}
 		}
 	}
 
 	r := &Response{}
-	err = json.NewDecoder(resp.Body).Decode(r)
+	err = json.Unmarshal(body, r)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(r.JSON.Errors) != 0 {
-		var msg []string
-		for _, k := range r.JSON.Errors {
-			msg = append(msg, k[1])
-		}
-		return nil, errors.New(strings.Join(msg, ", "))
-	}
 	session.modhash = r.JSON.Data.Modhash
 
 	return session, nil
@@ -99,27 +99,38 @@ func NewLoginSession(username, password, useragent string) (*LoginSession, error
 
 // Clear clears all session cookies and updates the current session with a new one.
 func (s LoginSession) Clear() error {
+	return s.ClearContext(context.Background())
+}
+
+// ClearContext is like Clear but with a context.
+func (s LoginSession) ClearContext(ctx context.Context) error {
 	req := &request{
-		url: "https://www.reddit.com/api/clear_sessions",
+		url: s.opts.wwwURL() + "/api/clear_sessions",
 		values: &url.Values{
 			"curpass": {s.password},
 			"uh":      {s.modhash},
 		},
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	status, body, err := req.response(ctx)
 	if err != nil {
 		return err
 	}
 
-	if !strings.Contains(body.String(), "all other sessions have been logged out") {
-		return errors.New("failed to clear session")
+	if !strings.Contains(string(body), "all other sessions have been logged out") {
+		return unexpectedError(status, body, "failed to clear session")
 	}
 	return nil
 }
 
 // Frontpage returns the submissions on the logged-in user's personal frontpage.
 func (s LoginSession) Frontpage(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.FrontpageContext(context.Background(), sort, params)
+}
+
+// FrontpageContext is like Frontpage but with a context.
+func (s LoginSession) FrontpageContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
 	v, err := query.Values(params)
 	if err != nil {
 		return nil, err
@@ -128,109 +139,108 @@ func (s LoginSession) Frontpage(sort PopularitySort, params ListingOptions) ([]*
 	var redditUrl string
 
 	if sort == DefaultPopularity {
-		redditUrl = fmt.Sprintf("https://www.reddit.com/.json?%s", v.Encode())
+		redditUrl = fmt.Sprintf("%s/.json?%s", s.opts.wwwURL(), v.Encode())
 	} else {
-		redditUrl = fmt.Sprintf("https://www.reddit.com/%s/.json?%s", sort, v.Encode())
+		redditUrl = fmt.Sprintf("%s/%s/.json?%s", s.opts.wwwURL(), sort, v.Encode())
 	}
 
 	req := request{
 		url:       redditUrl,
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
-	}
-	r := &Response{}
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	l := new(listing)
+	if err := json.NewDecoder(body).Decode(l); err != nil {
 		return nil, err
 	}
-
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
-	}
-
-	return submissions, nil
+	return l.submissions()
 }
 
 // Me returns an up-to-date redditor object of the logged-in user.
 func (s LoginSession) Me() (*Redditor, error) {
+	return s.MeContext(context.Background())
+}
+
+// MeContext is like Me but with a context.
+func (s LoginSession) MeContext(ctx context.Context) (*Redditor, error) {
 	req := &request{
-		url:       "https://www.reddit.com/api/me.json",
+		url:       s.opts.wwwURL() + "/api/me.json",
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data Redditor
-	}
-	r := &Response{}
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	var t Thing
+	if err := json.NewDecoder(body).Decode(&t); err != nil {
 		return nil, err
 	}
-
-	return &r.Data, nil
+	return thingData[*Redditor](&t)
 }
 
-func (s LoginSession) Submit(ns *NewSubmission) error {
+// Submit submits a new link or self post and returns it, along with the
+// error if saving it failed.
+func (s LoginSession) Submit(ns *NewSubmission) (*Submission, error) {
+	return s.SubmitContext(context.Background(), ns)
+}
 
-	var kind string
+// SubmitContext is like Submit but with a context.
+func (s LoginSession) SubmitContext(ctx context.Context, ns *NewSubmission) (*Submission, error) {
+	if ns.Media != nil || len(ns.Gallery) > 0 || len(ns.PollOptions) > 0 {
+		return nil, errors.New("media and poll submissions require an OAuthSession")
+	}
 
-	if ns.Self {
-		kind = "self"
-	} else {
-		kind = "link"
+	v := ns.values()
+	v.Set("uh", s.modhash)
+	if ns.Captcha != nil {
+		v.Set("captcha", ns.Captcha.Response)
+		v.Set("iden", ns.Captcha.Iden)
 	}
 
 	req := &request{
-		url: "https://www.reddit.com/api/submit",
-		values: &url.Values{
-			"title":       {ns.Title},
-			"url":         {ns.Content},
-			"text":        {ns.Content},
-			"sr":          {ns.Subreddit},
-			"kind":        {kind},
-			"sendreplies": {strconv.FormatBool(ns.SendReplies)},
-			"resubmit":    {strconv.FormatBool(ns.Resubmit)},
-			"extension":   {"json"},
-			"captcha":     {ns.Captcha.Response},
-			"iden":        {ns.Captcha.Iden},
-			"uh":          {s.modhash},
-		},
+		url:       s.opts.wwwURL() + "/api/submit",
+		values:    &v,
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if strings.Contains(body.String(), "error") {
-		return errors.New("failed to submit")
+
+	var r submitResponse
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return nil, err
 	}
-	return nil
+	sub := r.submission(ns)
+	if ns.Save && sub.FullID != "" {
+		if err := s.SaveContext(ctx, sub, ""); err != nil {
+			return sub, fmt.Errorf("saving submission: %w", err)
+		}
+	}
+	return sub, nil
 }
 
 // Vote either votes or rescinds a vote for a Submission or Comment.
 func (s LoginSession) Vote(v Voter, vote Vote) error {
+	return s.VoteContext(context.Background(), v, vote)
+}
+
+// VoteContext is like Vote but with a context.
+func (s LoginSession) VoteContext(ctx context.Context, v Voter, vote Vote) error {
 	req := &request{
-		url: "https://www.reddit.com/api/vote",
+		url: s.opts.wwwURL() + "/api/vote",
 		values: &url.Values{
 			"id":  {v.voteID()},
 			"dir": {string(vote)},
@@ -238,61 +248,123 @@ func (s LoginSession) Vote(v Voter, vote Vote) error {
 		},
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	status, body, err := req.response(ctx)
 	if err != nil {
 		return err
 	}
-	if body.String() != "{}" {
-		return errors.New("failed to vote")
+	if string(body) != "{}" {
+		return unexpectedError(status, body, "failed to vote")
 	}
 	return nil
 }
 
 // Reply posts a comment as a response to a Submission or Comment.
-func (s LoginSession) Reply(r Replier, comment string) error {
+func (s LoginSession) Reply(r Replier, comment string) (*Comment, error) {
+	return s.ReplyContext(context.Background(), r, comment)
+}
+
+// ReplyContext is like Reply but with a context.
+func (s LoginSession) ReplyContext(ctx context.Context, r Replier, comment string) (*Comment, error) {
 	req := &request{
-		url: "https://www.reddit.com/api/comment",
+		url: s.opts.wwwURL() + "/api/comment",
 		values: &url.Values{
+			"api_type": {"json"},
 			"thing_id": {r.replyID()},
 			"text":     {comment},
 			"uh":       {s.modhash},
 		},
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	body, err := req.getResponse()
+	status, body, err := req.response(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !strings.Contains(body.String(), "data") {
-		return errors.New("failed to post comment")
+	var res struct {
+		JSON struct {
+			Data struct {
+				Things []struct {
+					Data *Comment
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if len(res.JSON.Data.Things) == 0 {
+		return nil, unexpectedError(status, body, "failed to post comment")
 	}
+	return res.JSON.Data.Things[0].Data, nil
+}
 
-	return nil
+// Save saves a link or comment.
+func (s LoginSession) Save(v Voter, category string) error {
+	return s.SaveContext(context.Background(), v, category)
+}
+
+// SaveContext is like Save but with a context.
+func (s LoginSession) SaveContext(ctx context.Context, v Voter, category string) error {
+	return s.save(ctx, "/api/save", v, category)
+}
+
+// Unsave unsaves a link or comment.
+func (s LoginSession) Unsave(v Voter, category string) error {
+	return s.UnsaveContext(context.Background(), v, category)
+}
+
+// UnsaveContext is like Unsave but with a context.
+func (s LoginSession) UnsaveContext(ctx context.Context, v Voter, category string) error {
+	return s.save(ctx, "/api/unsave", v, category)
+}
+
+func (s LoginSession) save(ctx context.Context, path string, v Voter, category string) error {
+	req := &request{
+		url: s.opts.wwwURL() + path,
+		values: &url.Values{
+			"id":       {v.voteID()},
+			"category": {category},
+			"uh":       {s.modhash},
+		},
+		cookie:    s.cookie,
+		useragent: s.useragent,
+		opts:      s.opts,
+	}
+	_, err := req.getResponse(ctx)
+	return err
 }
 
 // Delete deletes a Submission or Comment.
 func (s LoginSession) Delete(d Deleter) error {
+	return s.DeleteContext(context.Background(), d)
+}
+
+// DeleteContext is like Delete but with a context.
+func (s LoginSession) DeleteContext(ctx context.Context, d Deleter) error {
 	req := &request{
-		url: "https://www.reddit.com/api/del",
+		url: s.opts.wwwURL() + "/api/del",
 		values: &url.Values{
 			"id": {d.deleteID()},
 			"uh": {s.modhash},
 		},
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	body, err := req.getResponse()
+	status, body, err := req.response(ctx)
 	if err != nil {
 		return err
 	}
 
-	if !strings.Contains(body.String(), "data") {
-		return errors.New("failed to delete item")
+	// Reddit answers a successful deletion with an empty object.
+	if b := strings.TrimSpace(string(body)); b != "{}" && !strings.Contains(b, "data") {
+		return unexpectedError(status, body, "failed to delete item")
 	}
 
 	return nil
@@ -300,13 +372,19 @@ func (s LoginSession) Delete(d Deleter) error {
 
 // NeedsCaptcha returns true if captcha is required, false if it isn't
 func (s LoginSession) NeedsCaptcha() (bool, error) {
+	return s.NeedsCaptchaContext(context.Background())
+}
+
+// NeedsCaptchaContext is like NeedsCaptcha but with a context.
+func (s LoginSession) NeedsCaptchaContext(ctx context.Context) (bool, error) {
 	req := &request{
-		url:       "https://www.reddit.com/api/needs_captcha.json",
+		url:       s.opts.wwwURL() + "/api/needs_captcha.json",
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 
 	if err != nil {
 		return false, err
@@ -323,15 +401,21 @@ func (s LoginSession) NeedsCaptcha() (bool, error) {
 
 // NewCaptchaIden gets a new captcha iden from reddit
 func (s LoginSession) NewCaptchaIden() (string, error) {
+	return s.NewCaptchaIdenContext(context.Background())
+}
+
+// NewCaptchaIdenContext is like NewCaptchaIden but with a context.
+func (s LoginSession) NewCaptchaIdenContext(ctx context.Context) (string, error) {
 	req := &request{
-		url: "https://www.reddit.com/api/new_captcha",
+		url: s.opts.wwwURL() + "/api/new_captcha",
 		values: &url.Values{
 			"api_type": {"json"},
 		},
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -355,85 +439,145 @@ func (s LoginSession) NewCaptchaIden() (string, error) {
 	return r.JSON.Data.Iden, nil
 }
 
-// Listing returns a listing for an user
-func (s LoginSession) Listing(username, listing string, sort PopularitySort, after string) ([]*Submission, error) {
-	values := &url.Values{}
+// Listing returns a listing for an user, such as "overview" or "saved",
+// which may mix comments and submissions.
+func (s LoginSession) Listing(username, listing string, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.ListingContext(context.Background(), username, listing, sort, params)
+}
+
+// ListingContext is like Listing but with a context.
+func (s LoginSession) ListingContext(ctx context.Context, username, where string, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	l, err := s.userListing(ctx, username, where, sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.things()
+}
+
+// userListing fetches the given listing of a user.
+func (s LoginSession) userListing(ctx context.Context, username, where string, sort PopularitySort, params ListingOptions) (*listing, error) {
+	values, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
 	if sort != "" {
 		values.Set("sort", string(sort))
 	}
-	if after != "" {
-		values.Set("after", after)
-	}
-	url := fmt.Sprintf("https://www.reddit.com/user/%s/%s.json?%s", username, listing, values.Encode())
 	req := &request{
-		url:       url,
+		url:       fmt.Sprintf("%s/user/%s/%s.json?%s", s.opts.wwwURL(), username, where, values.Encode()),
 		cookie:    s.cookie,
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
-	}
-
-	r := &Response{}
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	l := new(listing)
+	if err := json.NewDecoder(body).Decode(l); err != nil {
 		return nil, err
 	}
-
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
-	}
-
-	return submissions, nil
+	return l, nil
 }
 
 // Fetch the Overview listing for the logged-in user
-func (s LoginSession) MyOverview(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "overview", sort, after)
+func (s LoginSession) MyOverview(sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.MyOverviewContext(context.Background(), sort, params)
+}
+
+// MyOverviewContext is like MyOverview but with a context.
+func (s LoginSession) MyOverviewContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.ListingContext(ctx, s.username, "overview", sort, params)
 }
 
 // Fetch the Submitted listing for the logged-in user
-func (s LoginSession) MySubmitted(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "submitted", sort, after)
+func (s LoginSession) MySubmitted(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.MySubmittedContext(context.Background(), sort, params)
+}
+
+// MySubmittedContext is like MySubmitted but with a context.
+func (s LoginSession) MySubmittedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := s.userListing(ctx, s.username, "submitted", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 // Fetch the Comments listing for the logged-in user
-func (s LoginSession) MyComments(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "comments", sort, after)
+func (s LoginSession) MyComments(sort PopularitySort, params ListingOptions) ([]*Comment, error) {
+	return s.MyCommentsContext(context.Background(), sort, params)
+}
+
+// MyCommentsContext is like MyComments but with a context.
+func (s LoginSession) MyCommentsContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Comment, error) {
+	l, err := s.userListing(ctx, s.username, "comments", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.comments()
 }
 
 // Fetch the Liked listing for the logged-in user
-func (s LoginSession) MyLiked(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "liked", sort, after)
+func (s LoginSession) MyLiked(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.MyLikedContext(context.Background(), sort, params)
+}
+
+// MyLikedContext is like MyLiked but with a context.
+func (s LoginSession) MyLikedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := s.userListing(ctx, s.username, "liked", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 // Fetch the Disliked listing for the logged-in user
-func (s LoginSession) MyDisliked(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "disliked", sort, after)
+func (s LoginSession) MyDisliked(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.MyDislikedContext(context.Background(), sort, params)
+}
+
+// MyDislikedContext is like MyDisliked but with a context.
+func (s LoginSession) MyDislikedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := s.userListing(ctx, s.username, "disliked", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 // Fetch the Hidden listing for the logged-in user
-func (s LoginSession) MyHidden(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "hidden", sort, after)
+func (s LoginSession) MyHidden(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.MyHiddenContext(context.Background(), sort, params)
+}
+
+// MyHiddenContext is like MyHidden but with a context.
+func (s LoginSession) MyHiddenContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := s.userListing(ctx, s.username, "hidden", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 // Fetch the Saved listing for the logged-in user
-func (s LoginSession) MySaved(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "saved", sort, after)
+func (s LoginSession) MySaved(sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.MySavedContext(context.Background(), sort, params)
+}
+
+// MySavedContext is like MySaved but with a context.
+func (s LoginSession) MySavedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.ListingContext(ctx, s.username, "saved", sort, params)
 }
 
 // Fetch the Gilded listing for the logged-in user
-func (s LoginSession) MyGilded(sort PopularitySort, after string) ([]*Submission, error) {
-	return s.Listing(s.username, "gilded", sort, after)
+func (s LoginSession) MyGilded(sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.MyGildedContext(context.Background(), sort, params)
+}
+
+// MyGildedContext is like MyGilded but with a context.
+func (s LoginSession) MyGildedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return s.ListingContext(ctx, s.username, "gilded", sort, params)
 }
@@ -0,0 +1,128 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrAccessDenied is returned by LocalCodeAuth when the user declines
+	// the authorization.
+	ErrAccessDenied = errors.New("user denied the authorization")
+	// ErrStateMismatch is returned by LocalCodeAuth when the redirect
+	// carries a state other than the one sent, which hints at a CSRF
+	// attack.
+	ErrStateMismatch = errors.New("authorization state mismatch")
+)
+
+// LocalCodeAuth runs the whole authorization code flow for programs running
+// on the user's machine. It listens on the redirect URL of the session,
+// which must be a local http URL such as "http://localhost:8080/callback",
+// and calls open with the URL the user must visit, e.g. to print it or to
+// start a browser. Once reddit redirects back, the returned code is
+// exchanged for a token with CodeAuth. It gives up after timeout, unless it
+// is zero.
+func (o *OAuthSession) LocalCodeAuth(scopes []string, d TokenDuration, timeout time.Duration, open func(authURL string) error) error {
+	return o.LocalCodeAuthContext(context.Background(), scopes, d, timeout, open)
+}
+
+// LocalCodeAuthContext is like LocalCodeAuth but with a context.
+func (o *OAuthSession) LocalCodeAuthContext(ctx context.Context, scopes []string, d TokenDuration, timeout time.Duration, open func(authURL string) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	redirect, err := url.Parse(o.OAuthConfig.RedirectURL)
+	if err != nil {
+		return err
+	}
+	if redirect.Scheme != "http" {
+		return fmt.Errorf("redirect URL %q is not a local http URL", o.OAuthConfig.RedirectURL)
+	}
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return err
+	}
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		var err error
+		switch {
+		case q.Get("state") != state:
+			err = ErrStateMismatch
+		case q.Get("error") == "access_denied":
+			err = ErrAccessDenied
+		case q.Get("error") != "":
+			err = &APIError{Code: q.Get("error"), Message: "authorization failed"}
+		case q.Get("code") == "":
+			err = errors.New("redirect lacks an authorization code")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			select {
+			case errs <- err:
+			default:
+			}
+			return
+		}
+
+		fmt.Fprintln(w, "Authorization complete, you may close this window.")
+		select {
+		case codes <- q.Get("code"):
+		default:
+		}
+	})}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	if err := open(o.AuthCodeURLWithDuration(state, scopes, d)); err != nil {
+		return err
+	}
+
+	select {
+	case code := <-codes:
+		return o.CodeAuthContext(ctx, code)
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// randomState returns a random state string for AuthCodeURL.
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// loopbackSession returns a session redirecting to a free local port and
+// exchanging codes with a fake token endpoint.
+func loopbackSession(t *testing.T) *OAuthSession {
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "abc" {
+			t.Errorf("unexpected code: %s", r.FormValue("code"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"access_token": "user", "token_type": "bearer", "expires_in": 3600}`)
+	}))
+	t.Cleanup(auth.Close)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	o, err := NewOAuthSession("id", "secret", "agent", "http://"+addr+"/callback", WithAuthURL(auth.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+// redirect simulates reddit redirecting the browser, with query built from
+// the state of authURL.
+func redirect(t *testing.T, o *OAuthSession, authURL string, query func(state string) url.Values) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		resp, err := http.Get(o.OAuthConfig.RedirectURL + "?" + query(u.Query().Get("state")).Encode())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+func TestLocalCodeAuth(t *testing.T) {
+	o := loopbackSession(t)
+	err := o.LocalCodeAuth([]string{"read"}, PermanentDuration, 5*time.Second, func(authURL string) error {
+		redirect(t, o, authURL, func(state string) url.Values {
+			return url.Values{"state": {state}, "code": {"abc"}}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := o.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "user" {
+		t.Fatalf("unexpected access token: %s", tok.AccessToken)
+	}
+}
+
+func TestLocalCodeAuthErrors(t *testing.T) {
+	tests := []struct {
+		query func(state string) url.Values
+		err   error
+	}{
+		{func(state string) url.Values { return url.Values{"state": {"forged"}, "code": {"abc"}} }, ErrStateMismatch},
+		{func(state string) url.Values { return url.Values{"state": {state}, "error": {"access_denied"}} }, ErrAccessDenied},
+	}
+	for _, tt := range tests {
+		o := loopbackSession(t)
+		err := o.LocalCodeAuth(nil, TemporaryDuration, 5*time.Second, func(authURL string) error {
+			redirect(t, o, authURL, tt.query)
+			return nil
+		})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("LocalCodeAuth() returned %v, want %v", err, tt.err)
+		}
+	}
+}
+
+func TestLocalCodeAuthTimeout(t *testing.T) {
+	o := loopbackSession(t)
+	err := o.LocalCodeAuth(nil, TemporaryDuration, 10*time.Millisecond, func(string) error { return nil })
+	if err == nil {
+		t.Fatal("LocalCodeAuth() did not time out")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+// Media is the embedded media of a submission: either a video hosted by
+// reddit or an oEmbed of a third-party site.
+type Media struct {
+	Type        string       `json:"type"`
+	OEmbed      *OEmbed      `json:"oembed"`
+	RedditVideo *RedditVideo `json:"reddit_video"`
+}
+
+// OEmbed describes media embedded from a third-party site.
+type OEmbed struct {
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	HTML            string `json:"html"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	AuthorName      string `json:"author_name"`
+	AuthorURL       string `json:"author_url"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	ThumbnailURL    string `json:"thumbnail_url"`
+	ThumbnailWidth  int    `json:"thumbnail_width"`
+	ThumbnailHeight int    `json:"thumbnail_height"`
+}
+
+// RedditVideo describes a video hosted by reddit.
+type RedditVideo struct {
+	FallbackURL       string `json:"fallback_url"`
+	DashURL           string `json:"dash_url"`
+	HLSURL            string `json:"hls_url"`
+	ScrubberMediaURL  string `json:"scrubber_media_url"`
+	Width             int    `json:"width"`
+	Height            int    `json:"height"`
+	Duration          int    `json:"duration"`
+	BitrateKbps       int    `json:"bitrate_kbps"`
+	IsGIF             bool   `json:"is_gif"`
+	TranscodingStatus string `json:"transcoding_status"`
+}
+
+// Preview holds the preview images reddit generated for a submission.
+type Preview struct {
+	Enabled            bool           `json:"enabled"`
+	Images             []PreviewImage `json:"images"`
+	RedditVideoPreview *RedditVideo   `json:"reddit_video_preview"`
+}
+
+// PreviewImage is a preview image in several resolutions. Variants holds
+// the other formats of the image, such as "gif", "mp4" or "nsfw".
+type PreviewImage struct {
+	ID          string                    `json:"id"`
+	Source      ImageSource               `json:"source"`
+	Resolutions []ImageSource             `json:"resolutions"`
+	Variants    map[string]PreviewVariant `json:"variants"`
+}
+
+// PreviewVariant is a format of a PreviewImage.
+type PreviewVariant struct {
+	Source      ImageSource   `json:"source"`
+	Resolutions []ImageSource `json:"resolutions"`
+}
+
+// ImageSource is an image of a given size. Its URL is HTML escaped.
+type ImageSource struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// GalleryData lists the items of a gallery submission in order.
+type GalleryData struct {
+	Items []GalleryItem `json:"items"`
+}
+
+// GalleryItem is an item of a gallery. Its media is described by the
+// MediaMetadata of the submission with the same MediaID.
+type GalleryItem struct {
+	ID          int    `json:"id"`
+	MediaID     string `json:"media_id"`
+	Caption     string `json:"caption"`
+	OutboundURL string `json:"outbound_url"`
+}
+
+// MediaMetadata describes an image or video of a gallery or of a rich
+// text post.
+type MediaMetadata struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Kind is "Image", "AnimatedImage" or "RedditVideo".
+	Kind     string        `json:"e"`
+	MIMEType string        `json:"m"`
+	Source   *MediaSource  `json:"s"`
+	Previews []MediaSource `json:"p"`
+}
+
+// MediaSource is a rendition of a MediaMetadata. Images have a URL,
+// animated images a GIF and an MP4 URL.
+type MediaSource struct {
+	URL    string `json:"u"`
+	GIF    string `json:"gif"`
+	MP4    string `json:"mp4"`
+	Width  int    `json:"x"`
+	Height int    `json:"y"`
+}
+
+// PollData describes the poll of a poll submission.
+type PollData struct {
+	TotalVoteCount int          `json:"total_vote_count"`
+	Options        []PollOption `json:"options"`
+	// VotingEndTimestamp is when voting ends, in milliseconds since the
+	// Unix epoch.
+	VotingEndTimestamp float64 `json:"voting_end_timestamp"`
+	// UserSelection is the ID of the option the user voted for, if any.
+	UserSelection string `json:"user_selection"`
+}
+
+// PollOption is an option of a poll. VoteCount is only known once voting
+// ended or the user voted.
+type PollOption struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	VoteCount int    `json:"vote_count"`
+}
@@ -0,0 +1,26 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+// Message represents a private message or a comment reply notification in
+// a reddit inbox.
+type Message struct {
+	Author       string  `json:"author"`
+	Dest         string  `json:"dest"`
+	Subject      string  `json:"subject"`
+	Body         string  `json:"body"`
+	BodyHTML     string  `json:"body_html"`
+	Subreddit    string  `json:"subreddit"`
+	ID           string  `json:"id"`
+	FullID       string  `json:"name"`
+	ParentID     string  `json:"parent_id"`
+	FirstMessage string  `json:"first_message_name"`
+	Context      string  `json:"context"`
+	Created      float64 `json:"created_utc"`
+	IsNew        bool    `json:"new"`
+	WasComment   bool    `json:"was_comment"`
+}
+
+func (m Message) replyID() string { return m.FullID }
@@ -0,0 +1,260 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxMoreChildren is the most comment IDs /api/morechildren accepts at once.
+const maxMoreChildren = 100
+
+// moreChildrenFunc fetches the given comments of a submission from
+// /api/morechildren.
+type moreChildrenFunc func(ctx context.Context, linkID string, ids []string, sort PopularitySort) (*listing, error)
+
+// moreChildrenValues returns the query of a /api/morechildren request.
+func moreChildrenValues(linkID string, ids []string, sort PopularitySort) url.Values {
+	v := url.Values{
+		"api_type":       {"json"},
+		"link_id":        {linkID},
+		"children":       {strings.Join(ids, ",")},
+		"limit_children": {"false"},
+	}
+	if sort != "" {
+		v.Set("sort", string(sort))
+	}
+	return v
+}
+
+// decodeMoreChildren decodes the things of a /api/morechildren response
+// into a listing.
+func decodeMoreChildren(body []byte) (*listing, error) {
+	var r struct {
+		JSON struct {
+			Data struct {
+				Things []Thing
+			}
+		}
+	}
+	if err := jsonError(http.StatusOK, body); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+
+	l := new(listing)
+	l.Data.Children = r.JSON.Data.Things
+	return l, nil
+}
+
+// expandMore replaces the given placeholders of t by the comments they
+// stand for, fetching them in batches. It returns the number of comments
+// added to t.
+func expandMore(ctx context.Context, t *Thread, more []*MoreComments, sort PopularitySort, fetch moreChildrenFunc) (int, error) {
+	var ids []string
+	var expanded []*MoreComments
+	for _, m := range more {
+		// "Continue this thread" placeholders list no children.
+		if len(m.Children) == 0 {
+			continue
+		}
+		ids = append(ids, m.Children...)
+		expanded = append(expanded, m)
+	}
+	if len(expanded) == 0 {
+		return 0, nil
+	}
+
+	var comments []*Comment
+	var placeholders []*MoreComments
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxMoreChildren {
+			n = maxMoreChildren
+		}
+
+		l, err := fetch(ctx, t.Submission.FullID, ids[:n], sort)
+		if err != nil {
+			return 0, err
+		}
+		c, err := l.comments()
+		if err != nil {
+			return 0, err
+		}
+		m, err := l.moreComments()
+		if err != nil {
+			return 0, err
+		}
+		comments = append(comments, c...)
+		placeholders = append(placeholders, m...)
+		ids = ids[n:]
+	}
+
+	t.splice(expanded, comments, placeholders)
+	return len(comments), nil
+}
+
+// expandThread expands the placeholders of t until none is left that can
+// be expanded, or reddit stops returning new comments.
+func expandThread(ctx context.Context, t *Thread, sort PopularitySort, fetch moreChildrenFunc) error {
+	for {
+		more := t.expandable()
+		if len(more) == 0 {
+			return nil
+		}
+		n, err := expandMore(ctx, t, more, sort, fetch)
+		if err != nil || n == 0 {
+			return err
+		}
+	}
+}
+
+// expandable returns the placeholders of t listing children.
+func (t *Thread) expandable() []*MoreComments {
+	var more []*MoreComments
+	add := func(ms []*MoreComments) {
+		for _, m := range ms {
+			if len(m.Children) > 0 {
+				more = append(more, m)
+			}
+		}
+	}
+
+	add(t.More)
+	var walk func([]*Comment)
+	walk = func(comments []*Comment) {
+		for _, c := range comments {
+			add(c.More)
+			walk(c.Replies)
+		}
+	}
+	walk(t.Comments)
+	return more
+}
+
+// splice removes the expanded placeholders from t and inserts the fetched
+// comments and placeholders below their parents.
+func (t *Thread) splice(expanded []*MoreComments, comments []*Comment, more []*MoreComments) {
+	done := make(map[*MoreComments]bool, len(expanded))
+	for _, m := range expanded {
+		done[m] = true
+	}
+	remove := func(ms []*MoreComments) []*MoreComments {
+		kept := ms[:0]
+		for _, m := range ms {
+			if !done[m] {
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	}
+
+	byID := make(map[string]*Comment)
+	t.More = remove(t.More)
+	var walk func([]*Comment)
+	walk = func(cs []*Comment) {
+		for _, c := range cs {
+			byID[c.FullID] = c
+			c.More = remove(c.More)
+			walk(c.Replies)
+		}
+	}
+	walk(t.Comments)
+
+	// Index all fetched comments first, reddit may list a reply before
+	// its parent.
+	for _, c := range comments {
+		byID[c.FullID] = c
+	}
+	for _, c := range comments {
+		if parent, ok := byID[c.ParentID]; ok && parent != c {
+			parent.Replies = append(parent.Replies, c)
+		} else {
+			t.Comments = append(t.Comments, c)
+		}
+	}
+	for _, m := range more {
+		if parent, ok := byID[m.ParentID]; ok {
+			parent.More = append(parent.More, m)
+		} else {
+			t.More = append(t.More, m)
+		}
+	}
+}
+
+// ExpandMore replaces the given "load more comments" placeholders of t by
+// the comments they stand for.
+func (s Session) ExpandMore(t *Thread, more []*MoreComments, sort PopularitySort) error {
+	return s.ExpandMoreContext(context.Background(), t, more, sort)
+}
+
+// ExpandMoreContext is like ExpandMore but with a context.
+func (s Session) ExpandMoreContext(ctx context.Context, t *Thread, more []*MoreComments, sort PopularitySort) error {
+	_, err := expandMore(ctx, t, more, sort, s.moreChildren)
+	return err
+}
+
+// ExpandThread expands the placeholders of t until the whole thread is
+// fetched.
+func (s Session) ExpandThread(t *Thread, sort PopularitySort) error {
+	return s.ExpandThreadContext(context.Background(), t, sort)
+}
+
+// ExpandThreadContext is like ExpandThread but with a context.
+func (s Session) ExpandThreadContext(ctx context.Context, t *Thread, sort PopularitySort) error {
+	return expandThread(ctx, t, sort, s.moreChildren)
+}
+
+func (s Session) moreChildren(ctx context.Context, linkID string, ids []string, sort PopularitySort) (*listing, error) {
+	req := request{
+		url:       fmt.Sprintf("%s/api/morechildren.json?%s", s.opts.wwwURL(), moreChildrenValues(linkID, ids, sort).Encode()),
+		useragent: s.useragent,
+		opts:      s.opts,
+	}
+	body, err := req.getResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMoreChildren(body.Bytes())
+}
+
+// ExpandMore replaces the given "load more comments" placeholders of t by
+// the comments they stand for using OAuth.
+func (o *OAuthSession) ExpandMore(t *Thread, more []*MoreComments, sort PopularitySort) error {
+	return o.ExpandMoreContext(context.Background(), t, more, sort)
+}
+
+// ExpandMoreContext is like ExpandMore but with a context.
+func (o *OAuthSession) ExpandMoreContext(ctx context.Context, t *Thread, more []*MoreComments, sort PopularitySort) error {
+	_, err := expandMore(ctx, t, more, sort, o.moreChildren)
+	return err
+}
+
+// ExpandThread expands the placeholders of t until the whole thread is
+// fetched using OAuth.
+func (o *OAuthSession) ExpandThread(t *Thread, sort PopularitySort) error {
+	return o.ExpandThreadContext(context.Background(), t, sort)
+}
+
+// ExpandThreadContext is like ExpandThread but with a context.
+func (o *OAuthSession) ExpandThreadContext(ctx context.Context, t *Thread, sort PopularitySort) error {
+	return expandThread(ctx, t, sort, o.moreChildren)
+}
+
+func (o *OAuthSession) moreChildren(ctx context.Context, linkID string, ids []string, sort PopularitySort) (*listing, error) {
+	var body json.RawMessage
+	link := fmt.Sprintf("%s/api/morechildren?%s", o.opts.oauthURL(), moreChildrenValues(linkID, ids, sort).Encode())
+	if err := o.getBody(ctx, "read", link, &body); err != nil {
+		return nil, err
+	}
+	return decodeMoreChildren(body)
+}
@@ -5,20 +5,20 @@
 package geddit
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/beefsack/go-rate"
 	"github.com/google/go-querystring/query"
-	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 type transport struct {
@@ -41,14 +41,26 @@ type OAuthSession struct {
 	OAuthConfig  *oauth2.Config
 	//TokenExpiry  time.Time
 	UserAgent string
-	ctx       context.Context
-	throttle  *rate.RateLimiter
+	// OnTokenRefresh, if set, is called with the new token whenever the
+	// access token is refreshed, e.g. to persist it.
+	OnTokenRefresh func(*oauth2.Token)
+	// TokenStore, if set, is where new and refreshed tokens are saved.
+	TokenStore TokenStore
+	// OnTokenStoreError, if set, is called when a refreshed token cannot
+	// be saved to TokenStore. The request which needed the new token is
+	// sent anyway.
+	OnTokenStoreError func(error)
+	ctx               context.Context
+	throttle          *rate.RateLimiter
+	opts              options
+	tokenSource       oauth2.TokenSource
+	scopes            *scopeSet
 }
 
 // NewOAuthSession creates a new session for those who want to log into a
 // reddit account via OAuth.
-func NewOAuthSession(clientID, clientSecret, useragent, redirectURL string) (*OAuthSession, error) {
-	o := &OAuthSession{}
+func NewOAuthSession(clientID, clientSecret, useragent, redirectURL string, opts ...Option) (*OAuthSession, error) {
+	o := &OAuthSession{opts: newOptions(opts)}
 
 	if len(useragent) > 0 {
 		o.UserAgent = useragent
@@ -61,19 +73,60 @@ func NewOAuthSession(clientID, clientSecret, useragent, redirectURL string) (*OA
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://www.reddit.com/api/v1/authorize",
-			TokenURL: "https://www.reddit.com/api/v1/access_token",
+			AuthURL:  o.opts.authURL() + "/api/v1/authorize",
+			TokenURL: o.opts.authURL() + "/api/v1/access_token",
 		},
 		RedirectURL: redirectURL,
 	}
 	// Inject our custom HTTP client so that a user-defined UA can
 	// be passed during any authentication requests.
-	c := &http.Client{}
-	c.Transport = &transport{http.DefaultTransport, o.UserAgent}
-	o.ctx = context.WithValue(context.Background(), oauth2.HTTPClient, c)
+	c := *o.opts.httpClient()
+	rt := c.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	c.Transport = &transport{rt, o.UserAgent}
+	o.ctx = context.WithValue(context.Background(), oauth2.HTTPClient, &c)
+	return o, nil
+}
+
+// ResumeOAuthSession creates a session authenticated with the token kept
+// in store, refreshing it if it expired. Refreshed tokens are saved back to
+// store. ErrNoToken is returned if store holds no token.
+func ResumeOAuthSession(clientID, clientSecret, useragent, redirectURL string, store TokenStore, opts ...Option) (*OAuthSession, error) {
+	o, err := NewOAuthSession(clientID, clientSecret, useragent, redirectURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	o.TokenStore = store
+	switch grant, _ := t.Extra("grant_type").(string); grant {
+	case "":
+		o.SetToken(t)
+	case "client_credentials":
+		o.setAppToken(o.appConfig(nil), t)
+	default:
+		deviceID, _ := t.Extra("device_id").(string)
+		o.setAppToken(o.appConfig(url.Values{"grant_type": {grant}, "device_id": {deviceID}}), t)
+	}
+
+	if !t.Valid() {
+		if _, err := o.Token(); err != nil {
+			return nil, err
+		}
+	}
 	return o, nil
 }
 
+// RateLimiter returns the RateLimiter pacing the requests of the session.
+func (o *OAuthSession) RateLimiter() *RateLimiter {
+	return o.opts.limiter
+}
+
 // Throttle sets the interval of each HTTP request.
 // Disable by setting interval to 0. Disabled by default.
 // Throttling is applied to invidual OAuthSession types.
@@ -87,22 +140,29 @@ func (o *OAuthSession) Throttle(interval time.Duration) {
 
 // LoginAuth creates the required HTTP client with a new token.
 func (o *OAuthSession) LoginAuth(username, password string) error {
-	// Fetch OAuth token.
-	t, err := o.OAuthConfig.PasswordCredentialsToken(o.ctx, username, password)
+	return o.LoginAuthContext(context.Background(), username, password)
+}
+
+// LoginAuthContext is like LoginAuth but with a context.
+func (o *OAuthSession) LoginAuthContext(ctx context.Context, username, password string) error {
+	password, err := o.opts.password(password)
 	if err != nil {
 		return err
 	}
+
+	// Fetch OAuth token.
+	t, err := o.OAuthConfig.PasswordCredentialsToken(o.authContext(ctx), username, password)
+	if err != nil {
+		return o.opts.otpError(tokenError(err))
+	}
 	if !t.Valid() {
-		msg := "Invalid OAuth token"
-		if t != nil {
-			if extra := t.Extra("error"); extra != nil {
-				msg = fmt.Sprintf("%s: %s", msg, extra)
-			}
+		e := &APIError{StatusCode: http.StatusOK, Message: "Invalid OAuth token"}
+		if extra := t.Extra("error"); extra != nil {
+			e.Code = fmt.Sprint(extra)
 		}
-		return errors.New(msg)
+		return o.opts.otpError(e)
 	}
-	o.Client = o.OAuthConfig.Client(o.ctx, t)
-	return nil
+	return o.setToken(t)
 }
 
 // AuthCodeURL creates and returns an auth URL which contains an auth code.
@@ -111,20 +171,240 @@ func (o *OAuthSession) AuthCodeURL(state string, scopes []string) string {
 	return o.OAuthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline)
 }
 
+// AuthCodeURLWithDuration is like AuthCodeURL but requests an authorization
+// of the given duration. Use PermanentDuration to obtain a refresh token.
+func (o *OAuthSession) AuthCodeURLWithDuration(state string, scopes []string, d TokenDuration) string {
+	o.OAuthConfig.Scopes = scopes
+	return o.OAuthConfig.AuthCodeURL(state, oauth2.SetAuthURLParam("duration", string(d)))
+}
+
 // CodeAuth creates and sets a token using an authentication code returned from AuthCodeURL.
 func (o *OAuthSession) CodeAuth(code string) error {
-	t, err := o.OAuthConfig.Exchange(o.ctx, code)
+	return o.CodeAuthContext(context.Background(), code)
+}
+
+// CodeAuthContext is like CodeAuth but with a context.
+func (o *OAuthSession) CodeAuthContext(ctx context.Context, code string) error {
+	t, err := o.OAuthConfig.Exchange(o.authContext(ctx), code)
+	if err != nil {
+		return tokenError(err)
+	}
+	return o.setToken(t)
+}
+
+// SetToken creates the required HTTP client from an existing token. The
+// access token is refreshed automatically when it expires if t carries a
+// refresh token.
+func (o *OAuthSession) SetToken(t *oauth2.Token) {
+	o.setTokenSource(func(ctx context.Context, t *oauth2.Token) (*oauth2.Token, error) {
+		return o.OAuthConfig.TokenSource(ctx, t).Token()
+	}, t)
+}
+
+// AppAuth creates the required HTTP client with a token for the
+// application itself rather than a user, using the client_credentials
+// grant of confidential clients. Such a session can only call endpoints
+// which do not act on behalf of a user.
+func (o *OAuthSession) AppAuth() error {
+	return o.AppAuthContext(context.Background())
+}
+
+// AppAuthContext is like AppAuth but with a context.
+func (o *OAuthSession) AppAuthContext(ctx context.Context) error {
+	return o.appAuth(ctx, nil)
+}
+
+// InstalledAppAuth is like AppAuth for installed apps, which have no
+// client secret. The deviceID should be a unique 20-30 character ID per
+// device, or "DO_NOT_TRACK_THIS_DEVICE".
+func (o *OAuthSession) InstalledAppAuth(deviceID string) error {
+	return o.InstalledAppAuthContext(context.Background(), deviceID)
+}
+
+// InstalledAppAuthContext is like InstalledAppAuth but with a context.
+func (o *OAuthSession) InstalledAppAuthContext(ctx context.Context, deviceID string) error {
+	return o.appAuth(ctx, url.Values{
+		"grant_type": {"https://oauth.reddit.com/grants/installed_client"},
+		"device_id":  {deviceID},
+	})
+}
+
+// appAuth fetches an application-only token. Such tokens have no refresh
+// token, a new one is fetched whenever they expire.
+func (o *OAuthSession) appAuth(ctx context.Context, params url.Values) error {
+	c := o.appConfig(params)
+	t, err := c.Token(o.authContext(ctx))
+	if err != nil {
+		return tokenError(err)
+	}
+
+	t = withAppGrant(t, params)
+	o.setAppToken(c, t)
+	return o.saveToken(t)
+}
+
+// appConfig returns the config fetching application-only tokens, with the
+// grant_type and device_id of installed apps in params.
+func (o *OAuthSession) appConfig(params url.Values) *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:       o.OAuthConfig.ClientID,
+		ClientSecret:   o.OAuthConfig.ClientSecret,
+		TokenURL:       o.OAuthConfig.Endpoint.TokenURL,
+		EndpointParams: params,
+		AuthStyle:      oauth2.AuthStyleInHeader,
+	}
+}
+
+// setAppToken creates the required HTTP client from the application-only
+// token t, fetching a new one with c once it expires.
+func (o *OAuthSession) setAppToken(c *clientcredentials.Config, t *oauth2.Token) {
+	o.setTokenSource(func(ctx context.Context, _ *oauth2.Token) (*oauth2.Token, error) {
+		t, err := c.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return withAppGrant(t, c.EndpointParams), nil
+	}, t)
+}
+
+// withAppGrant returns the application-only token t along with the grant
+// it was fetched with, so that a session resumed from a TokenStore fetches
+// new tokens the same way.
+func withAppGrant(t *oauth2.Token, params url.Values) *oauth2.Token {
+	extra := map[string]interface{}{"grant_type": "client_credentials"}
+	if grant := params.Get("grant_type"); grant != "" {
+		extra["grant_type"] = grant
+		extra["device_id"] = params.Get("device_id")
+	}
+	if scope, ok := t.Extra("scope").(string); ok {
+		extra["scope"] = scope
+	}
+	return t.WithExtra(extra)
+}
+
+// setTokenSource creates the required HTTP client from the first token t,
+// using refresh to replace it once expired. The client refreshes the token
+// with the context of the request needing it.
+func (o *OAuthSession) setTokenSource(refresh func(context.Context, *oauth2.Token) (*oauth2.Token, error), t *oauth2.Token) {
+	ts := &notifyTokenSource{
+		refresh: refresh,
+		last:    t,
+		session: o,
+	}
+	base := http.DefaultTransport
+	if c, ok := o.baseContext().Value(oauth2.HTTPClient).(*http.Client); ok && c.Transport != nil {
+		base = c.Transport
+	}
+	o.tokenSource = ts
+	o.scopes = newScopeSet(t)
+	o.Client = &http.Client{Transport: &tokenTransport{source: ts, base: base}}
+}
+
+// baseContext returns the context carrying the HTTP client used for the
+// token requests made in the background.
+func (o *OAuthSession) baseContext() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// Token returns the current token of the session, refreshing it first if
+// it expired.
+func (o *OAuthSession) Token() (*oauth2.Token, error) {
+	return o.TokenContext(o.baseContext())
+}
+
+// TokenContext is like Token but with a context, which cancels the
+// refresh.
+func (o *OAuthSession) TokenContext(ctx context.Context) (*oauth2.Token, error) {
+	ts, ok := o.tokenSource.(*notifyTokenSource)
+	if !ok {
+		return nil, errors.New("OAuth Session lacks a token! Use func (o OAuthSession) LoginAuth() to make one.")
+	}
+	return ts.tokenContext(o.authContext(ctx))
+}
+
+// TokenSource returns the TokenSource supplying the tokens of the session,
+// or nil before authentication.
+func (o *OAuthSession) TokenSource() oauth2.TokenSource {
+	return o.tokenSource
+}
+
+func (o *OAuthSession) tokenRefreshed(t *oauth2.Token) {
+	o.scopes.update(t)
+	if o.OnTokenRefresh != nil {
+		o.OnTokenRefresh(t)
+	}
+	if err := o.saveToken(t); err != nil && o.OnTokenStoreError != nil {
+		o.OnTokenStoreError(err)
+	}
+}
+
+// setToken sets the token obtained by an authentication and saves it.
+func (o *OAuthSession) setToken(t *oauth2.Token) error {
+	o.SetToken(t)
+	return o.saveToken(t)
+}
+
+func (o *OAuthSession) saveToken(t *oauth2.Token) error {
+	if o.TokenStore == nil {
+		return nil
+	}
+	return o.TokenStore.Save(t)
+}
+
+// Revoke revokes the token of the session, so that it can no longer be
+// used, and forgets it. A refresh token is revoked along with all access
+// tokens obtained with it. The session must authenticate again before
+// making further calls, and the TokenStore, if any, is cleared.
+func (o *OAuthSession) Revoke() error {
+	return o.RevokeContext(context.Background())
+}
+
+// RevokeContext is like Revoke but with a context.
+func (o *OAuthSession) RevokeContext(ctx context.Context) error {
+	ts, ok := o.tokenSource.(*notifyTokenSource)
+	if !ok {
+		return errors.New("OAuth Session lacks a token! Use func (o OAuthSession) LoginAuth() to make one.")
+	}
+	t := ts.current()
+
+	form := url.Values{"token": {t.AccessToken}, "token_type_hint": {"access_token"}}
+	if t.RefreshToken != "" {
+		form = url.Values{"token": {t.RefreshToken}, "token_type_hint": {"refresh_token"}}
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.opts.authURL()+"/api/v1/revoke_token", strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
-	o.Client = o.OAuthConfig.Client(o.ctx, t)
-	return nil
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", o.UserAgent)
+	req.SetBasicAuth(url.QueryEscape(o.OAuthConfig.ClientID), url.QueryEscape(o.OAuthConfig.ClientSecret))
+	// The revocation endpoint is on the auth host, outside the OAuth budget.
+	opts := o.opts.unlimited()
+	if _, _, err := opts.do(opts.httpClient(), req); err != nil {
+		return err
+	}
+
+	o.Client = nil
+	o.tokenSource = nil
+	o.scopes = nil
+	if o.TokenStore == nil {
+		return nil
+	}
+	return o.TokenStore.Delete()
 }
 
 // NeedsCaptcha check whether CAPTCHAs are needed for the Submit function.
 func (o *OAuthSession) NeedsCaptcha() (bool, error) {
+	return o.NeedsCaptchaContext(context.Background())
+}
+
+// NeedsCaptchaContext is like NeedsCaptcha but with a context.
+func (o *OAuthSession) NeedsCaptchaContext(ctx context.Context) (bool, error) {
 	var b bool
-	err := o.getBody("https://oauth.reddit.com/api/needs_captcha", &b)
+	err := o.getBody(ctx, "", o.opts.oauthURL()+"/api/needs_captcha", &b)
 	if err != nil {
 		return false, err
 	}
@@ -133,6 +413,11 @@ func (o *OAuthSession) NeedsCaptcha() (bool, error) {
 
 // NewCaptcha returns a string used to create CAPTCHA links for users.
 func (o *OAuthSession) NewCaptcha() (string, error) {
+	return o.NewCaptchaContext(context.Background())
+}
+
+// NewCaptchaContext is like NewCaptcha but with a context.
+func (o *OAuthSession) NewCaptchaContext(ctx context.Context) (string, error) {
 	// Build form for POST request.
 	v := url.Values{
 		"api_type": {"json"},
@@ -148,15 +433,45 @@ func (o *OAuthSession) NewCaptcha() (string, error) {
 	}
 	c := &captcha{}
 
-	err := o.postBody("https://oauth.reddit.com/api/new_captcha", v, c)
+	err := o.postBody(ctx, "", o.opts.oauthURL()+"/api/new_captcha", v, c)
 	if err != nil {
 		return "", err
 	}
 	return c.Json.Data.Iden, nil
 }
 
-func (o *OAuthSession) getBody(link string, d interface{}) error {
-	req, err := http.NewRequest("GET", link, nil)
+// authContext returns ctx carrying the HTTP client used for token requests.
+func (o *OAuthSession) authContext(ctx context.Context) context.Context {
+	if o.ctx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, o.ctx.Value(oauth2.HTTPClient))
+}
+
+// wait blocks until the throttle allows another request or ctx is done.
+func (o *OAuthSession) wait(ctx context.Context) error {
+	if o.throttle == nil {
+		return ctx.Err()
+	}
+	for {
+		ok, d := o.throttle.Try()
+		if ok {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// getBody fetches link, which requires the given OAuth scope, and decodes
+// the response into d.
+func (o *OAuthSession) getBody(ctx context.Context, scope, link string, d interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
 	if err != nil {
 		return err
 	}
@@ -164,19 +479,16 @@ func (o *OAuthSession) getBody(link string, d interface{}) error {
 	if o.Client == nil {
 		return errors.New("OAuth Session lacks HTTP client! Use func (o OAuthSession) LoginAuth() to make one.")
 	}
-
-	// Throttle request
-	if o.throttle != nil {
-		o.throttle.Wait()
+	if err := o.requireScope(scope); err != nil {
+		return err
 	}
 
-	resp, err := o.Client.Do(req)
-	if err != nil {
+	// Throttle request
+	if err := o.wait(ctx); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	_, body, err := o.opts.do(o.Client, req)
 	if err != nil {
 		return err
 	}
@@ -188,8 +500,13 @@ func (o *OAuthSession) getBody(link string, d interface{}) error {
 }
 
 func (o *OAuthSession) Me() (*Redditor, error) {
+	return o.MeContext(context.Background())
+}
+
+// MeContext is like Me but with a context.
+func (o *OAuthSession) MeContext(ctx context.Context) (*Redditor, error) {
 	r := &Redditor{}
-	err := o.getBody("https://oauth.reddit.com/api/v1/me", r)
+	err := o.getBody(ctx, "identity", o.opts.oauthURL()+"/api/v1/me", r)
 	if err != nil {
 		return nil, err
 	}
@@ -197,11 +514,16 @@ func (o *OAuthSession) Me() (*Redditor, error) {
 }
 
 func (o *OAuthSession) MyKarma() ([]Karma, error) {
+	return o.MyKarmaContext(context.Background())
+}
+
+// MyKarmaContext is like MyKarma but with a context.
+func (o *OAuthSession) MyKarmaContext(ctx context.Context) ([]Karma, error) {
 	type karma struct {
 		Data []Karma
 	}
 	k := &karma{}
-	err := o.getBody("https://oauth.reddit.com/api/v1/me/karma", k)
+	err := o.getBody(ctx, "mysubreddits", o.opts.oauthURL()+"/api/v1/me/karma", k)
 	if err != nil {
 		return nil, err
 	}
@@ -209,8 +531,13 @@ func (o *OAuthSession) MyKarma() ([]Karma, error) {
 }
 
 func (o *OAuthSession) MyPreferences() (*Preferences, error) {
+	return o.MyPreferencesContext(context.Background())
+}
+
+// MyPreferencesContext is like MyPreferences but with a context.
+func (o *OAuthSession) MyPreferencesContext(ctx context.Context) (*Preferences, error) {
 	p := &Preferences{}
-	err := o.getBody("https://oauth.reddit.com/api/v1/me/prefs", p)
+	err := o.getBody(ctx, "identity", o.opts.oauthURL()+"/api/v1/me/prefs", p)
 	if err != nil {
 		return nil, err
 	}
@@ -218,13 +545,18 @@ func (o *OAuthSession) MyPreferences() (*Preferences, error) {
 }
 
 func (o *OAuthSession) MyFriends() ([]Friend, error) {
+	return o.MyFriendsContext(context.Background())
+}
+
+// MyFriendsContext is like MyFriends but with a context.
+func (o *OAuthSession) MyFriendsContext(ctx context.Context) ([]Friend, error) {
 	type friends struct {
 		Data struct {
 			Children []Friend
 		}
 	}
 	f := &friends{}
-	err := o.getBody("https://oauth.reddit.com/api/v1/me/friends", f)
+	err := o.getBody(ctx, "mysubreddits", o.opts.oauthURL()+"/api/v1/me/friends", f)
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +564,11 @@ func (o *OAuthSession) MyFriends() ([]Friend, error) {
 }
 
 func (o *OAuthSession) MyTrophies() ([]*Trophy, error) {
+	return o.MyTrophiesContext(context.Background())
+}
+
+// MyTrophiesContext is like MyTrophies but with a context.
+func (o *OAuthSession) MyTrophiesContext(ctx context.Context) ([]*Trophy, error) {
 	type trophyData struct {
 		Data struct {
 			Trophies []struct {
@@ -241,7 +578,7 @@ func (o *OAuthSession) MyTrophies() ([]*Trophy, error) {
 	}
 
 	t := &trophyData{}
-	err := o.getBody("https://oauth.reddit.com/api/v1/me/trophies", t)
+	err := o.getBody(ctx, "identity", o.opts.oauthURL()+"/api/v1/me/trophies", t)
 	if err != nil {
 		return nil, err
 	}
@@ -253,67 +590,103 @@ func (o *OAuthSession) MyTrophies() ([]*Trophy, error) {
 	return myTrophies, nil
 }
 
-// Listing returns a slice of Submission pointers.
+// Listing returns a listing of the given user, such as "overview" or
+// "saved", which may mix comments and submissions.
 // See https://www.reddit.com/dev/api#listings for documentation.
-func (o *OAuthSession) Listing(username, listing string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	p, err := query.Values(params)
+func (o *OAuthSession) Listing(username, listing string, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	return o.ListingContext(context.Background(), username, listing, sort, params)
+}
+
+// ListingContext is like Listing but with a context.
+func (o *OAuthSession) ListingContext(ctx context.Context, username, listing string, sort PopularitySort, params ListingOptions) ([]Thing, error) {
+	l, err := o.userListing(ctx, username, listing, sort, params)
 	if err != nil {
 		return nil, err
 	}
-	if sort != "" {
-		p.Set("sort", string(sort))
-	}
+	return l.things()
+}
 
-	type resp struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
+// ListingIterator returns a ListingIterator over a listing of the given
+// user, such as "upvoted" or "saved".
+func (o *OAuthSession) ListingIterator(username, where string, sort PopularitySort, params ListingOptions) *ListingIterator[Thing] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return o.userListing(ctx, username, where, sort, params)
 	}
-	r := &resp{}
-	url := fmt.Sprintf("https://oauth.reddit.com/user/%s/%s?%s", username, listing, p.Encode())
-	err = o.getBody(url, r)
+	return newListingIterator(params, fetch, (*listing).things)
+}
+
+// userListing fetches the given listing of a user.
+func (o *OAuthSession) userListing(ctx context.Context, username, where string, sort PopularitySort, params ListingOptions) (*listing, error) {
+	link := fmt.Sprintf("%s/user/%s/%s", o.opts.oauthURL(), username, where)
+	return o.getListing(ctx, "history", link, sort, params)
+}
+
+// getListing fetches the Listing at link, which requires the given scope.
+func (o *OAuthSession) getListing(ctx context.Context, scope, link string, sort PopularitySort, params ListingOptions) (*listing, error) {
+	v, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
-
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
+	if sort != "" {
+		v.Set("sort", string(sort))
 	}
 
-	return submissions, nil
+	l := new(listing)
+	if err := o.getBody(ctx, scope, link+"?"+v.Encode(), l); err != nil {
+		return nil, err
+	}
+	return l, nil
 }
 
 func (o *OAuthSession) Upvoted(username string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	return o.Listing(username, "upvoted", sort, params)
+	return o.UpvotedContext(context.Background(), username, sort, params)
+}
+
+// UpvotedContext is like Upvoted but with a context.
+func (o *OAuthSession) UpvotedContext(ctx context.Context, username string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := o.userListing(ctx, username, "upvoted", sort, params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 func (o *OAuthSession) MyUpvoted(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	me, err := o.Me()
+	return o.MyUpvotedContext(context.Background(), sort, params)
+}
+
+// MyUpvotedContext is like MyUpvoted but with a context.
+func (o *OAuthSession) MyUpvotedContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	me, err := o.MeContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return o.Listing(me.Name, "upvoted", sort, params)
+	return o.UpvotedContext(ctx, me.Name, sort, params)
 }
 
 // AboutRedditor returns a Redditor for the given username using OAuth.
 func (o *OAuthSession) AboutRedditor(user string) (*Redditor, error) {
-	type redditor struct {
-		Data Redditor
-	}
-	r := &redditor{}
-	link := fmt.Sprintf("https://oauth.reddit.com/user/%s/about", user)
+	return o.AboutRedditorContext(context.Background(), user)
+}
+
+// AboutRedditorContext is like AboutRedditor but with a context.
+func (o *OAuthSession) AboutRedditorContext(ctx context.Context, user string) (*Redditor, error) {
+	var t Thing
+	link := fmt.Sprintf("%s/user/%s/about", o.opts.oauthURL(), user)
 
-	err := o.getBody(link, r)
+	err := o.getBody(ctx, "read", link, &t)
 	if err != nil {
 		return nil, err
 	}
-	return &r.Data, nil
+	return thingData[*Redditor](&t)
 }
 
 func (o *OAuthSession) UserTrophies(user string) ([]*Trophy, error) {
+	return o.UserTrophiesContext(context.Background(), user)
+}
+
+// UserTrophiesContext is like UserTrophies but with a context.
+func (o *OAuthSession) UserTrophiesContext(ctx context.Context, user string) ([]*Trophy, error) {
 	type trophyData struct {
 		Data struct {
 			Trophies []struct {
@@ -323,8 +696,8 @@ func (o *OAuthSession) UserTrophies(user string) ([]*Trophy, error) {
 	}
 
 	t := &trophyData{}
-	url := fmt.Sprintf("https://oauth.reddit.com/api/v1/user/%s/trophies", user)
-	err := o.getBody(url, t)
+	url := fmt.Sprintf("%s/api/v1/user/%s/trophies", o.opts.oauthURL(), user)
+	err := o.getBody(ctx, "read", url, t)
 	if err != nil {
 		return nil, err
 	}
@@ -338,176 +711,232 @@ func (o *OAuthSession) UserTrophies(user string) ([]*Trophy, error) {
 
 // AboutSubreddit returns a subreddit for the given subreddit name using OAuth.
 func (o *OAuthSession) AboutSubreddit(name string) (*Subreddit, error) {
-	type subreddit struct {
-		Data Subreddit
-	}
-	sr := &subreddit{}
-	link := fmt.Sprintf("https://oauth.reddit.com/r/%s/about", name)
+	return o.AboutSubredditContext(context.Background(), name)
+}
 
-	err := o.getBody(link, sr)
+// AboutSubredditContext is like AboutSubreddit but with a context.
+func (o *OAuthSession) AboutSubredditContext(ctx context.Context, name string) (*Subreddit, error) {
+	var t Thing
+	link := fmt.Sprintf("%s/r/%s/about", o.opts.oauthURL(), name)
+
+	err := o.getBody(ctx, "read", link, &t)
 	if err != nil {
 		return nil, err
 	}
-	return &sr.Data, nil
+	return thingData[*Subreddit](&t)
 }
 
 // Comments returns the comments for a given Submission using OAuth.
 func (o *OAuthSession) Comments(h *Submission, sort PopularitySort, params ListingOptions) ([]*Comment, error) {
-	p, err := query.Values(params)
+	return o.CommentsContext(context.Background(), h, sort, params)
+}
+
+// CommentsContext is like Comments but with a context.
+func (o *OAuthSession) CommentsContext(ctx context.Context, h *Submission, sort PopularitySort, params ListingOptions) ([]*Comment, error) {
+	t, err := o.ThreadContext(ctx, h.ID, sort, params)
 	if err != nil {
 		return nil, err
 	}
+	return t.Comments, nil
+}
+
+// Thread returns the submission with the given ID along with its tree of
+// comments using OAuth.
+func (o *OAuthSession) Thread(submissionID string, sort PopularitySort, params ListingOptions) (*Thread, error) {
+	return o.ThreadContext(context.Background(), submissionID, sort, params)
+}
 
+// ThreadContext is like Thread but with a context.
+func (o *OAuthSession) ThreadContext(ctx context.Context, submissionID string, sort PopularitySort, params ListingOptions) (*Thread, error) {
+	p, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
 	if sort != "" {
 		p.Set("sort", string(sort))
 	}
 
-	var c interface{}
-	link := fmt.Sprintf("https://oauth.reddit.com/comments/%s?%s", h.ID, p.Encode())
-	err = o.getBody(link, &c)
-	if err != nil {
+	var ls []listing
+	link := fmt.Sprintf("%s/comments/%s?%s", o.opts.oauthURL(), submissionID, p.Encode())
+	if err := o.getBody(ctx, "read", link, &ls); err != nil {
 		return nil, err
 	}
-	helper := new(helper)
-	helper.buildComments(c)
-	return helper.comments, nil
+	return newThread(ls)
+}
+
+// postBody posts form to link, which requires the given OAuth scope, and
+// decodes the response into d unless it is nil.
+func (o *OAuthSession) postBody(ctx context.Context, scope, link string, form url.Values, d interface{}) error {
+	_, _, err := o.post(ctx, scope, link, formContentType, []byte(form.Encode()), d)
+	return err
 }
 
-func (o *OAuthSession) postBody(link string, form url.Values, d interface{}) error {
-	req, err := http.NewRequest("POST", link, strings.NewReader(form.Encode()))
+// postJSON posts v encoded as JSON to link, which requires the given
+// OAuth scope, and decodes the response into d unless it is nil.
+func (o *OAuthSession) postJSON(ctx context.Context, scope, link string, v, d interface{}) error {
+	b, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
+	_, _, err = o.post(ctx, scope, link, "application/json", b, d)
+	return err
+}
+
+// formContentType is the Content-Type of a URL encoded form.
+const formContentType = "application/x-www-form-urlencoded"
+
+// post sends payload to link, which requires the given OAuth scope, and
+// decodes the response into d unless it is nil. It also returns the status
+// code and body of the response, for callers that check them further.
+func (o *OAuthSession) post(ctx context.Context, scope, link, contentType string, payload []byte, d interface{}) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", link, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
 
 	// This is needed to avoid rate limits
 	//req.Header.Set("User-Agent", o.UserAgent)
 
-	// POST form provided
-	req.PostForm = form
+	req.Header.Set("Content-Type", contentType)
 
 	if o.Client == nil {
-		return errors.New("OAuth Session lacks HTTP client! Use func (o OAuthSession) LoginAuth() to make one.")
+		return 0, nil, errors.New("OAuth Session lacks HTTP client! Use func (o OAuthSession) LoginAuth() to make one.")
+	}
+	if err := o.requireScope(scope); err != nil {
+		return 0, nil, err
 	}
 
 	// Throttle request
-	if o.throttle != nil {
-		o.throttle.Wait()
+	if err := o.wait(ctx); err != nil {
+		return 0, nil, err
 	}
 
-	resp, err := o.Client.Do(req)
+	resp, body, err := o.opts.do(o.Client, req)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	if err := jsonError(resp.StatusCode, body); err != nil {
+		return 0, nil, err
 	}
 
 	// The caller may want JSON decoded, or this could just be an update/delete request.
 	if d != nil {
 		err = json.Unmarshal(body, d)
 		if err != nil {
-			return err
+			return 0, nil, err
 		}
 	}
 
-	return nil
+	return resp.StatusCode, body, nil
 }
 
-// Submit accepts a NewSubmission type and submits a new link using OAuth.
-// Returns a Submission type.
+// Submit accepts a NewSubmission type and submits a new link, text, media,
+// gallery or poll post using OAuth. Returns a Submission type, along with
+// the error if saving it failed.
 func (o *OAuthSession) Submit(ns *NewSubmission) (*Submission, error) {
+	return o.SubmitContext(context.Background(), ns)
+}
 
-	// Build form for POST request.
-	v := url.Values{
-		"title":       {ns.Title},
-		"url":         {ns.Content},
-		"text":        {ns.Content},
-		"sr":          {ns.Subreddit},
-		"sendreplies": {strconv.FormatBool(ns.SendReplies)},
-		"resubmit":    {strconv.FormatBool(ns.Resubmit)},
-		"api_type":    {"json"},
+// SubmitContext is like Submit but with a context.
+func (o *OAuthSession) SubmitContext(ctx context.Context, ns *NewSubmission) (*Submission, error) {
+	var r submitResponse
+	var err error
+	switch {
+	case len(ns.Gallery) > 0:
+		err = o.submitGallery(ctx, ns, &r)
+	case len(ns.PollOptions) > 0:
+		var post pollPost
+		if post, err = ns.poll(); err != nil {
+			return nil, err
+		}
+		err = o.postJSON(ctx, "submit", o.opts.oauthURL()+"/api/submit_poll_post.json", post, &r)
+	default:
 		// TODO implement captchas for OAuth types
-		//"captcha":     {ns.Captcha.Response},
-		//"iden":        {ns.Captcha.Iden},
-	}
-	if ns.Self {
-		v.Add("kind", "self")
-	} else {
-		v.Add("kind", "link")
-	}
-
-	type submission struct {
-		Json struct {
-			Errors [][]string
-			Data   Submission
+		v := ns.values()
+		if ns.Media != nil {
+			if err := o.submitMedia(ctx, ns, v); err != nil {
+				return nil, err
+			}
 		}
+		err = o.postBody(ctx, "submit", o.opts.oauthURL()+"/api/submit", v, &r)
 	}
-	submit := &submission{}
-
-	err := o.postBody("https://oauth.reddit.com/api/submit", v, submit)
 	if err != nil {
 		return nil, err
 	}
-	// TODO check s.Errors and do something useful?
-	return &submit.Json.Data, nil
+
+	sub := r.submission(ns)
+	if ns.Save && sub.FullID != "" {
+		if err := o.SaveContext(ctx, sub, ""); err != nil {
+			return sub, fmt.Errorf("saving submission: %w", err)
+		}
+	}
+	return sub, nil
 }
 
 // Delete deletes a link or comment using the given full name ID.
 func (o *OAuthSession) Delete(d Deleter) error {
+	return o.DeleteContext(context.Background(), d)
+}
+
+// DeleteContext is like Delete but with a context.
+func (o *OAuthSession) DeleteContext(ctx context.Context, d Deleter) error {
 	// Build form for POST request.
 	v := url.Values{}
 	v.Add("id", d.deleteID())
 
-	return o.postBody("https://oauth.reddit.com/api/del", v, nil)
+	return o.postBody(ctx, "edit", o.opts.oauthURL()+"/api/del", v, nil)
 }
 
 // SubredditSubmissions returns the submissions on the given subreddit using OAuth.
 func (o *OAuthSession) SubredditSubmissions(subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	v, err := query.Values(params)
-	if err != nil {
-		return nil, err
-	}
-
-	baseUrl := "https://oauth.reddit.com"
-
-	// If subbreddit given, add to URL
-	if subreddit != "" {
-		baseUrl += "/r/" + subreddit
-	}
-
-	redditURL := fmt.Sprintf(baseUrl+"/%s.json?%s", sort, v.Encode())
-
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
-	}
+	return o.SubredditSubmissionsContext(context.Background(), subreddit, sort, params)
+}
 
-	r := new(Response)
-	err = o.getBody(redditURL, r)
+// SubredditSubmissionsContext is like SubredditSubmissions but with a context.
+func (o *OAuthSession) SubredditSubmissionsContext(ctx context.Context, subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := o.getListing(ctx, "read", o.subredditURL(subreddit, sort), "", params)
 	if err != nil {
 		return nil, err
 	}
+	return l.submissions()
+}
 
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
+// SubredditSubmissionsIterator returns a ListingIterator over the
+// submissions on the given subreddit using OAuth.
+func (o *OAuthSession) SubredditSubmissionsIterator(subreddit string, sort PopularitySort, params ListingOptions) *ListingIterator[*Submission] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return o.getListing(ctx, "read", o.subredditURL(subreddit, sort), "", params)
 	}
+	return newListingIterator(params, fetch, (*listing).submissions)
+}
 
-	return submissions, nil
+// subredditURL returns the URL of the submissions on the given subreddit,
+// or on the frontpage if subreddit is empty.
+func (o *OAuthSession) subredditURL(subreddit string, sort PopularitySort) string {
+	baseURL := o.opts.oauthURL()
+	if subreddit != "" {
+		baseURL += "/r/" + subreddit
+	}
+	return fmt.Sprintf("%s/%s.json", baseURL, sort)
 }
 
 // Frontpage returns the submissions on the default reddit frontpage using OAuth.
 func (o *OAuthSession) Frontpage(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	return o.SubredditSubmissions("", sort, params)
+	return o.FrontpageContext(context.Background(), sort, params)
+}
+
+// FrontpageContext is like Frontpage but with a context.
+func (o *OAuthSession) FrontpageContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return o.SubredditSubmissionsContext(ctx, "", sort, params)
 }
 
 // Vote either votes or rescinds a vote for a Submission or Comment using OAuth.
 func (o *OAuthSession) Vote(v Voter, dir Vote) error {
+	return o.VoteContext(context.Background(), v, dir)
+}
+
+// VoteContext is like Vote but with a context.
+func (o *OAuthSession) VoteContext(ctx context.Context, v Voter, dir Vote) error {
 	// Build form for POST request.
 	form := url.Values{
 		"id":  {v.voteID()},
@@ -515,7 +944,7 @@ func (o *OAuthSession) Vote(v Voter, dir Vote) error {
 	}
 	var vo interface{}
 
-	err := o.postBody("https://oauth.reddit.com/api/vote", form, vo)
+	err := o.postBody(ctx, "vote", o.opts.oauthURL()+"/api/vote", form, vo)
 	if err != nil {
 		return err
 	}
@@ -523,7 +952,12 @@ func (o *OAuthSession) Vote(v Voter, dir Vote) error {
 }
 
 // Reply posts a comment as a response to a Submission or Comment using OAuth.
-func (o OAuthSession) Reply(r Replier, comment string) (*Comment, error) {
+func (o *OAuthSession) Reply(r Replier, comment string) (*Comment, error) {
+	return o.ReplyContext(context.Background(), r, comment)
+}
+
+// ReplyContext is like Reply but with a context.
+func (o *OAuthSession) ReplyContext(ctx context.Context, r Replier, comment string) (*Comment, error) {
 	// Build form for POST request.
 	form := url.Values{
 		"api_type": {"json"},
@@ -533,10 +967,9 @@ func (o OAuthSession) Reply(r Replier, comment string) (*Comment, error) {
 
 	type response struct {
 		JSON struct {
-			Errors [][]string
-			Data   struct {
+			Data struct {
 				Things []struct {
-					Data map[string]interface{}
+					Data *Comment
 				}
 			}
 		}
@@ -544,26 +977,24 @@ func (o OAuthSession) Reply(r Replier, comment string) (*Comment, error) {
 
 	res := &response{}
 
-	err := o.postBody("https://oauth.reddit.com/api/comment", form, res)
+	status, body, err := o.post(ctx, "submit", o.opts.oauthURL()+"/api/comment", formContentType, []byte(form.Encode()), res)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(res.JSON.Errors) != 0 {
-		var msg []string
-		for _, k := range res.JSON.Errors {
-			msg = append(msg, k[1])
-		}
-		return nil, errors.New(strings.Join(msg, ", "))
+	if len(res.JSON.Data.Things) == 0 {
+		return nil, unexpectedError(status, body, "failed to post comment")
 	}
-
-	c := makeComment(res.JSON.Data.Things[0].Data)
-
-	return c, nil
+	return res.JSON.Data.Things[0].Data, nil
 }
 
 // Save saves a link or comment using OAuth.
 func (o *OAuthSession) Save(v Voter, category string) error {
+	return o.SaveContext(context.Background(), v, category)
+}
+
+// SaveContext is like Save but with a context.
+func (o *OAuthSession) SaveContext(ctx context.Context, v Voter, category string) error {
 	// Build form for POST request.
 	form := url.Values{
 		"id":       {v.voteID()},
@@ -571,7 +1002,7 @@ func (o *OAuthSession) Save(v Voter, category string) error {
 	}
 	var s interface{}
 
-	err := o.postBody("https://oauth.reddit.com/api/save", form, s)
+	err := o.postBody(ctx, "save", o.opts.oauthURL()+"/api/save", form, s)
 	if err != nil {
 		return err
 	}
@@ -580,6 +1011,11 @@ func (o *OAuthSession) Save(v Voter, category string) error {
 
 // Unsave saves a link or comment using OAuth.
 func (o *OAuthSession) Unsave(v Voter, category string) error {
+	return o.UnsaveContext(context.Background(), v, category)
+}
+
+// UnsaveContext is like Unsave but with a context.
+func (o *OAuthSession) UnsaveContext(ctx context.Context, v Voter, category string) error {
 	// Build form for POST request.
 	form := url.Values{
 		"id":       {v.voteID()},
@@ -587,99 +1023,159 @@ func (o *OAuthSession) Unsave(v Voter, category string) error {
 	}
 	var u interface{}
 
-	err := o.postBody("https://oauth.reddit.com/api/unsave", form, u)
+	err := o.postBody(ctx, "save", o.opts.oauthURL()+"/api/unsave", form, u)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// Saved fetches the comments and links saved by given username using OAuth.
+func (o *OAuthSession) Saved(username string, params ListingOptions) ([]Thing, error) {
+	return o.SavedContext(context.Background(), username, params)
+}
+
+// SavedContext is like Saved but with a context.
+func (o *OAuthSession) SavedContext(ctx context.Context, username string, params ListingOptions) ([]Thing, error) {
+	return o.ListingContext(ctx, username, "saved", "", params)
+}
+
+// MySaved fetches the comments and links saved by current user using OAuth.
+func (o *OAuthSession) MySaved(params ListingOptions) ([]Thing, error) {
+	return o.MySavedContext(context.Background(), params)
+}
+
+// MySavedContext is like MySaved but with a context.
+func (o *OAuthSession) MySavedContext(ctx context.Context, params ListingOptions) ([]Thing, error) {
+	me, err := o.MeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.SavedContext(ctx, me.Name, params)
+}
+
 // SavedLinks fetches links saved by given username using OAuth.
 func (o *OAuthSession) SavedLinks(username string, params ListingOptions) ([]*Submission, error) {
-	return o.Listing(username, "saved", "", params)
+	return o.SavedLinksContext(context.Background(), username, params)
+}
+
+// SavedLinksContext is like SavedLinks but with a context.
+func (o *OAuthSession) SavedLinksContext(ctx context.Context, username string, params ListingOptions) ([]*Submission, error) {
+	l, err := o.userListing(ctx, username, "saved", "", params)
+	if err != nil {
+		return nil, err
+	}
+	return l.submissions()
 }
 
 // MySavedLinks fetches links saved by current user using OAuth.
 func (o *OAuthSession) MySavedLinks(params ListingOptions) ([]*Submission, error) {
-	me, err := o.Me()
+	return o.MySavedLinksContext(context.Background(), params)
+}
+
+// MySavedLinksContext is like MySavedLinks but with a context.
+func (o *OAuthSession) MySavedLinksContext(ctx context.Context, params ListingOptions) ([]*Submission, error) {
+	me, err := o.MeContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return o.Listing(me.Name, "saved", "", params)
+	return o.SavedLinksContext(ctx, me.Name, params)
 }
 
 // SavedComments fetches comments saved by given username using OAuth.
 func (o *OAuthSession) SavedComments(user string, params ListingOptions) ([]*Comment, error) {
-	var s interface{}
-	url := fmt.Sprintf("https://oauth.reddit.com/user/%s/saved", user)
-	err := o.getBody(url, &s)
+	return o.SavedCommentsContext(context.Background(), user, params)
+}
+
+// SavedCommentsContext is like SavedComments but with a context.
+func (o *OAuthSession) SavedCommentsContext(ctx context.Context, user string, params ListingOptions) ([]*Comment, error) {
+	l, err := o.userListing(ctx, user, "saved", "", params)
 	if err != nil {
 		return nil, err
 	}
-
-	helper := new(helper)
-	helper.buildComments(s)
-	return helper.comments, nil
+	return l.comments()
 }
 
 // MySavedComments fetches comments saved by current user using OAuth.
 func (o *OAuthSession) MySavedComments(params ListingOptions) ([]*Comment, error) {
-	me, err := o.Me()
+	return o.MySavedCommentsContext(context.Background(), params)
+}
+
+// MySavedCommentsContext is like MySavedComments but with a context.
+func (o *OAuthSession) MySavedCommentsContext(ctx context.Context, params ListingOptions) ([]*Comment, error) {
+	me, err := o.MeContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return o.SavedComments(me.Name, params)
+	return o.SavedCommentsContext(ctx, me.Name, params)
 }
 
 // MySubreddits fetchs subreddits the current user subscribes to.
 // TODO support other endpoints https://www.reddit.com/dev/api/#GET_subreddits_mine_{where}
 func (o *OAuthSession) MySubreddits() ([]*Subreddit, error) {
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Subreddit
-			}
-		}
-	}
-	r := new(Response)
-	err := o.getBody("https://oauth.reddit.com/subreddits/mine/subscriber", r)
+	return o.MySubredditsContext(context.Background())
+}
+
+// MySubredditsContext is like MySubreddits but with a context.
+func (o *OAuthSession) MySubredditsContext(ctx context.Context) ([]*Subreddit, error) {
+	l, err := o.getListing(ctx, "mysubreddits", o.opts.oauthURL()+"/subreddits/mine/subscriber", "", ListingOptions{})
 	if err != nil {
 		return nil, err
 	}
-	s := make([]*Subreddit, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		s[i] = child.Data
+	return l.subreddits()
+}
+
+// MySubredditsIterator returns a ListingIterator over the subreddits the
+// current user subscribes to.
+func (o *OAuthSession) MySubredditsIterator(params ListingOptions) *ListingIterator[*Subreddit] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return o.getListing(ctx, "mysubreddits", o.opts.oauthURL()+"/subreddits/mine/subscriber", "", params)
 	}
-	return s, nil
+	return newListingIterator(params, fetch, (*listing).subreddits)
 }
 
 // SubredditComments fetches all the new comments in a subreddit, and returns them in a slice of Comment structs
 // This function uses www.reddit.com instead of the OAuth API as the latter doesn't have an endpoint for a particular subreddit's comments
-func (o *OAuthSession) SubredditComments(subreddit string) ([]*Comment, error) {
-	baseURL := "https://www.reddit.com"
+func (o *OAuthSession) SubredditComments(subreddit string, params ListingOptions) ([]*Comment, error) {
+	return o.SubredditCommentsContext(context.Background(), subreddit, params)
+}
 
-	if subreddit != "" {
-		baseURL += "/r/" + subreddit
-	}
+// SubredditCommentsContext is like SubredditComments but with a context.
+func (o *OAuthSession) SubredditCommentsContext(ctx context.Context, subreddit string, params ListingOptions) ([]*Comment, error) {
+	return o.wwwSession().SubredditCommentsContext(ctx, subreddit, params)
+}
 
-	subCommentsURL := baseURL + "/comments.json"
+// wwwSession returns a Session for the www.reddit.com endpoints lacking an
+// OAuth equivalent. Their budget is not the OAuth client's, so they bypass
+// its rate limiter.
+func (o *OAuthSession) wwwSession() Session {
+	return Session{useragent: o.UserAgent, opts: o.opts.unlimited()}
+}
 
-	req := request{
-		url:       subCommentsURL,
-		useragent: o.UserAgent,
-	}
+// RedditorComments returns the comments of the given user using OAuth.
+func (o *OAuthSession) RedditorComments(username string, params ListingOptions) ([]*Comment, error) {
+	return o.RedditorCommentsContext(context.Background(), username, params)
+}
 
-	body, err := req.getResponse()
+// RedditorCommentsContext is like RedditorComments but with a context.
+func (o *OAuthSession) RedditorCommentsContext(ctx context.Context, username string, params ListingOptions) ([]*Comment, error) {
+	l, err := o.userListing(ctx, username, "comments", "", params)
 	if err != nil {
 		return nil, err
 	}
+	return l.comments()
+}
 
-	var comments interface{}
-	if err = json.NewDecoder(body).Decode(&comments); err != nil {
+// RedditorSubmissions returns the submissions of the given user using OAuth.
+func (o *OAuthSession) RedditorSubmissions(username string, params ListingOptions) ([]*Submission, error) {
+	return o.RedditorSubmissionsContext(context.Background(), username, params)
+}
+
+// RedditorSubmissionsContext is like RedditorSubmissions but with a context.
+func (o *OAuthSession) RedditorSubmissionsContext(ctx context.Context, username string, params ListingOptions) ([]*Submission, error) {
+	l, err := o.userListing(ctx, username, "submitted", "", params)
+	if err != nil {
 		return nil, err
 	}
-
-	helper := new(helper)
-	helper.buildComments(comments)
-	return helper.comments, nil
+	return l.submissions()
 }
@@ -5,31 +5,19 @@
 package geddit
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
-	"path"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-)
-
-type RewriteTransport struct {
-	Transport http.RoundTripper
-	URL       *url.URL
-}
+	"time"
 
-func (t RewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.URL.Scheme = t.URL.Scheme
-	req.URL.Host = t.URL.Host
-	req.URL.Path = path.Join(t.URL.Path, req.URL.Path)
-	rt := t.Transport
-	if rt == nil {
-		rt = http.DefaultTransport
-	}
-	return rt.RoundTrip(req)
-}
+	"golang.org/x/oauth2"
+)
 
 func testTools(code int, body string) (*httptest.Server, *OAuthSession) {
 	// Dummy server to write JSON body provided
@@ -39,12 +27,11 @@ func testTools(code int, body string) (*httptest.Server, *OAuthSession) {
 		fmt.Fprintln(w, body)
 	}))
 
-	u, err := url.Parse(server.URL)
-	if err != nil {
-		log.Fatalf("Failed to parse local server URL: %v", err)
+	o := &OAuthSession{
+		Client:    server.Client(),
+		UserAgent: "Geddit Test",
+		opts:      newOptions([]Option{WithBaseURL(server.URL), WithOAuthURL(server.URL)}),
 	}
-	o := &OAuthSession{Client: http.DefaultClient, UserAgent: "Geddit Test"}
-	o.Client.Transport = RewriteTransport{URL: u}
 
 	return server, o
 }
@@ -82,3 +69,455 @@ func TestMe(t *testing.T) {
 	fmt.Println(me)
 
 }
+
+func TestMeContextCanceled(t *testing.T) {
+	server, oauth := testTools(200, `{"name": "aggrolite"}`)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := oauth.MeContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("MeContext() with canceled context returned unexpected error: %v", err)
+	}
+}
+
+func TestSessionBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/r/golang/about.json" {
+			t.Errorf("unexpected request path: %s", r.URL.Path)
+		}
+		if ua := r.Header.Get("User-Agent"); ua != "Geddit Test" {
+			t.Errorf("unexpected user agent: %s", ua)
+		}
+		fmt.Fprintln(w, `{"kind": "t5", "data": {"display_name": "golang", "subscribers": 1}}`)
+	}))
+	defer server.Close()
+
+	s := NewSession("Geddit Test", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+	sr, err := s.AboutSubreddit("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Name != "golang" {
+		t.Fatalf("AboutSubreddit() returned unexpected name: %s", sr.Name)
+	}
+}
+
+func TestSubmitAPIError(t *testing.T) {
+	server, oauth := testTools(200, `{"json": {"ratelimit": 540.5, "errors": [["RATELIMIT", "you are doing that too much. try again in 9 minutes.", "ratelimit"]]}}`)
+	defer server.Close()
+
+	_, err := oauth.Submit(NewTextSubmission("golang", "title", "text", true, nil))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Submit() returned unexpected error: %v", err)
+	}
+	if apiErr.Code != "RATELIMIT" || apiErr.Field != "ratelimit" {
+		t.Fatalf("Submit() returned unexpected APIError: %#v", apiErr)
+	}
+	if apiErr.RetryAfter != 540500*time.Millisecond {
+		t.Fatalf("Submit() returned unexpected RetryAfter: %v", apiErr.RetryAfter)
+	}
+}
+
+func TestReplyAPIError(t *testing.T) {
+	server, oauth := testTools(200, `{"json": {"errors": [], "data": {"things": []}}}`)
+	defer server.Close()
+
+	_, err := oauth.Reply(&Submission{FullID: "t3_abc"}, "hi")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 200 || !strings.HasPrefix(apiErr.Message, "failed to post comment: unexpected response") {
+		t.Fatalf("Reply() returned unexpected error: %#v", err)
+	}
+}
+
+func TestMeStatusError(t *testing.T) {
+	server, oauth := testTools(403, `{"message": "Forbidden", "error": 403}`)
+	defer server.Close()
+
+	_, err := oauth.Me()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 403 {
+		t.Fatalf("Me() returned unexpected error: %v", err)
+	}
+}
+
+func TestPostBodyContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			t.Errorf("unexpected content type: %q", ct)
+		}
+		if id := r.PostFormValue("id"); id != "t3_abc" {
+			t.Errorf("unexpected form id: %q", id)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{}`)
+	}))
+	defer server.Close()
+
+	o := &OAuthSession{
+		Client:    server.Client(),
+		UserAgent: "Geddit Test",
+		opts:      newOptions([]Option{WithOAuthURL(server.URL)}),
+	}
+	if err := o.Vote(&Submission{FullID: "t3_abc"}, UpVote); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/access_token":
+			if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh" {
+				t.Errorf("unexpected token request: %v", r.Form)
+			}
+			fmt.Fprintln(w, `{"access_token": "new", "token_type": "bearer", "expires_in": 3600, "scope": "identity"}`)
+		case "/api/v1/me":
+			if auth := r.Header.Get("Authorization"); auth != "Bearer new" {
+				t.Errorf("unexpected authorization: %s", auth)
+			}
+			fmt.Fprintln(w, `{"name": "aggrolite"}`)
+		}
+	}))
+	defer server.Close()
+
+	o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL), WithOAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refreshed *oauth2.Token
+	o.OnTokenRefresh = func(t *oauth2.Token) { refreshed = t }
+	o.SetToken(&oauth2.Token{
+		AccessToken:  "old",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(-time.Hour),
+	})
+
+	if _, err := o.Me(); err != nil {
+		t.Fatal(err)
+	}
+	if refreshed == nil || refreshed.AccessToken != "new" {
+		t.Fatalf("OnTokenRefresh was called with %v", refreshed)
+	}
+	tok, err := o.Token()
+	if err != nil || tok.AccessToken != "new" || tok.RefreshToken != "refresh" {
+		t.Fatalf("Token() returned %v, %v", tok, err)
+	}
+}
+
+// failingTokenStore is a TokenStore whose Save always fails.
+type failingTokenStore struct{ MemoryTokenStore }
+
+func (s *failingTokenStore) Save(*oauth2.Token) error { return errors.New("disk full") }
+
+func TestTokenRefreshContext(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang the token refresh until the client gives up.
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL), WithOAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetToken(&oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := o.MeContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("MeContext() returned %v, want the context error", err)
+	}
+}
+
+func TestTokenRefreshSameAccessToken(t *testing.T) {
+	var refreshes, notified int
+	o := &OAuthSession{OnTokenRefresh: func(*oauth2.Token) { notified++ }}
+	o.setTokenSource(func(context.Context, *oauth2.Token) (*oauth2.Token, error) {
+		refreshes++
+		return &oauth2.Token{AccessToken: "a", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}, nil
+	}, &oauth2.Token{AccessToken: "a", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})
+
+	for i := 0; i < 3; i++ {
+		if tok, err := o.Token(); err != nil || !tok.Valid() {
+			t.Fatalf("Token() returned %v, %v", tok, err)
+		}
+	}
+	if refreshes != 1 || notified != 1 {
+		t.Fatalf("token refreshed %d times and notified %d times, want 1 and 1", refreshes, notified)
+	}
+}
+
+func TestTokenRefreshStoreError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/access_token":
+			fmt.Fprintln(w, `{"access_token": "new", "token_type": "bearer", "expires_in": 3600}`)
+		case "/api/v1/me":
+			fmt.Fprintln(w, `{"name": "aggrolite"}`)
+		}
+	}))
+	defer server.Close()
+
+	o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL), WithOAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.TokenStore = new(failingTokenStore)
+	var storeErr error
+	o.OnTokenStoreError = func(err error) { storeErr = err }
+	// The callback may use the session without deadlocking.
+	o.OnTokenRefresh = func(*oauth2.Token) {
+		if tok, err := o.Token(); err != nil || tok.AccessToken != "new" {
+			t.Errorf("Token() in OnTokenRefresh returned %v, %v", tok, err)
+		}
+	}
+	o.SetToken(&oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})
+
+	if _, err := o.Me(); err != nil {
+		t.Fatalf("Me() failed along with the token store: %v", err)
+	}
+	if storeErr == nil || storeErr.Error() != "disk full" {
+		t.Fatalf("OnTokenStoreError was called with %v", storeErr)
+	}
+}
+
+func TestResumeOAuthSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"access_token": "new", "token_type": "bearer", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	if _, err := ResumeOAuthSession("id", "secret", "agent", "", store); err != ErrNoToken {
+		t.Fatalf("ResumeOAuthSession() with an empty store returned %v", err)
+	}
+
+	expired := &oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	if err := store.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ResumeOAuthSession("id", "secret", "agent", "", store, WithAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Client == nil {
+		t.Fatal("ResumeOAuthSession() did not create an HTTP client")
+	}
+
+	saved, err := store.Load()
+	if err != nil || saved.AccessToken != "new" || saved.RefreshToken != "refresh" {
+		t.Fatalf("store holds %v, %v", saved, err)
+	}
+	fi, err := os.Stat(store.Path)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("token file has mode %v, %v", fi.Mode(), err)
+	}
+}
+
+func TestResumeOAuthSessionScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"access_token": "a", "token_type": "bearer", "expires_in": 3600, "scope": "read identity"}`)
+	}))
+	defer server.Close()
+
+	for _, store := range []TokenStore{
+		NewFileTokenStore(filepath.Join(t.TempDir(), "token.json")),
+		NewMemoryTokenStore(),
+	} {
+		o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.TokenStore = store
+		if err := o.LoginAuth("user", "hunter2"); err != nil {
+			t.Fatal(err)
+		}
+
+		o, err = ResumeOAuthSession("id", "secret", "agent", "", store, WithAuthURL(server.URL), WithOAuthURL(server.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := o.Scopes(); len(got) != 2 || got[0] != "identity" || got[1] != "read" {
+			t.Errorf("%T: resumed session has scopes %v", store, got)
+		}
+		if _, err := o.Submit(NewTextSubmission("golang", "title", "text", true, nil)); !errors.Is(err, ErrInsufficientScope) {
+			t.Errorf("%T: Submit() returned %v, want ErrInsufficientScope", store, err)
+		}
+	}
+}
+
+func TestInstalledAppAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/access_token":
+			if id, secret, _ := r.BasicAuth(); id != "id" || secret != "" {
+				t.Errorf("unexpected client credentials: %s:%s", id, secret)
+			}
+			if r.FormValue("grant_type") != "https://oauth.reddit.com/grants/installed_client" || r.FormValue("device_id") != "DO_NOT_TRACK_THIS_DEVICE" {
+				t.Errorf("unexpected token request: %v", r.Form)
+			}
+			fmt.Fprintln(w, `{"access_token": "app", "token_type": "bearer", "expires_in": 3600, "scope": "*"}`)
+		case "/r/golang/about":
+			if auth := r.Header.Get("Authorization"); auth != "Bearer app" {
+				t.Errorf("unexpected authorization: %s", auth)
+			}
+			fmt.Fprintln(w, `{"kind": "t5", "data": {"display_name": "golang"}}`)
+		}
+	}))
+	defer server.Close()
+
+	o, err := NewOAuthSession("id", "", "agent", "", WithAuthURL(server.URL), WithOAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.InstalledAppAuth("DO_NOT_TRACK_THIS_DEVICE"); err != nil {
+		t.Fatal(err)
+	}
+	sr, err := o.AboutSubreddit("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Name != "golang" {
+		t.Fatalf("AboutSubreddit() returned unexpected name: %s", sr.Name)
+	}
+}
+
+func TestResumeAppOnlyToken(t *testing.T) {
+	for _, tt := range []struct {
+		grant string
+		auth  func(*OAuthSession) error
+	}{
+		{"client_credentials", (*OAuthSession).AppAuth},
+		{"https://oauth.reddit.com/grants/installed_client", func(o *OAuthSession) error {
+			return o.InstalledAppAuth("DO_NOT_TRACK_THIS_DEVICE")
+		}},
+	} {
+		var n int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("grant_type") != tt.grant || r.FormValue("refresh_token") != "" {
+				t.Errorf("unexpected token request: %v", r.Form)
+			}
+			if tt.grant != "client_credentials" && r.FormValue("device_id") != "DO_NOT_TRACK_THIS_DEVICE" {
+				t.Errorf("unexpected device ID: %q", r.FormValue("device_id"))
+			}
+			n++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": "app%d", "token_type": "bearer", "expires_in": 3600, "scope": "*"}`, n)
+		}))
+
+		store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+		o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.TokenStore = store
+		if err := tt.auth(o); err != nil {
+			t.Fatal(err)
+		}
+
+		// Let the stored token expire.
+		tok, err := store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok.Expiry = time.Now().Add(-time.Hour)
+		if err := store.Save(tok); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ResumeOAuthSession("id", "secret", "agent", "", store, WithAuthURL(server.URL)); err != nil {
+			t.Fatalf("%s: ResumeOAuthSession() failed: %v", tt.grant, err)
+		}
+		tok, err = store.Load()
+		if err != nil || tok.AccessToken != "app2" || tok.Extra("grant_type") != tt.grant {
+			t.Fatalf("%s: store holds %v, %v", tt.grant, tok, err)
+		}
+		server.Close()
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/revoke_token" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		if id, secret, _ := r.BasicAuth(); id != "id" || secret != "secret" {
+			t.Errorf("unexpected client credentials: %s:%s", id, secret)
+		}
+		if r.FormValue("token") != "refresh" || r.FormValue("token_type_hint") != "refresh_token" {
+			t.Errorf("unexpected revoke request: %v", r.Form)
+		}
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", "60")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := NewMemoryTokenStore()
+	if err := store.Save(&oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ResumeOAuthSession("id", "secret", "agent", "", store, WithAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := o.Revoke(); err != nil {
+		t.Fatal(err)
+	}
+	if b := o.RateLimiter().Budget(); !b.Reset.IsZero() {
+		t.Fatalf("the revocation response updated the OAuth budget: %+v", b)
+	}
+	if o.Client != nil {
+		t.Fatal("Revoke() did not clear the HTTP client")
+	}
+	if _, err := store.Load(); err != ErrNoToken {
+		t.Fatalf("store still holds a token: %v", err)
+	}
+	if err := o.Revoke(); err == nil {
+		t.Fatal("Revoke() without a token did not fail")
+	}
+}
+
+func TestSubmitWithoutSaveScope(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"json": {"errors": [], "data": {"id": "abc", "name": "t3_abc", "url": "https://www.reddit.com/r/golang/comments/abc/title/"}}}`)
+	}))
+	defer server.Close()
+
+	o := &OAuthSession{
+		Client: server.Client(),
+		opts:   newOptions([]Option{WithOAuthURL(server.URL)}),
+		scopes: newScopeSet((&oauth2.Token{AccessToken: "a"}).WithExtra(map[string]interface{}{"scope": "submit identity"})),
+	}
+	sub, err := o.Submit(NewTextSubmission("golang", "title", "text", true, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.FullID != "t3_abc" || len(paths) != 1 || paths[0] != "/api/submit" {
+		t.Fatalf("Submit() returned %+v after requests to %v", sub, paths)
+	}
+
+	ns := NewTextSubmission("golang", "title", "text", true, nil)
+	ns.Save = true
+	sub, err = o.Submit(ns)
+	if !errors.Is(err, ErrInsufficientScope) || sub == nil || sub.FullID != "t3_abc" {
+		t.Fatalf("Submit() with Save returned %+v, %v", sub, err)
+	}
+}
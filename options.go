@@ -0,0 +1,104 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWWWURL   = "https://www.reddit.com"
+	defaultOAuthURL = "https://oauth.reddit.com"
+)
+
+// Option configures a Session, LoginSession or OAuthSession.
+type Option func(*options)
+
+// options holds the per-session settings. The zero value talks to
+// reddit.com using http.DefaultClient.
+type options struct {
+	www     string
+	oauth   string
+	auth    string
+	client  *http.Client
+	limiter *RateLimiter
+	retry   RetryPolicy
+	otp     func(time.Time) (string, error)
+}
+
+func newOptions(opts []Option) options {
+	o := options{retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.limiter == nil {
+		o.limiter = NewRateLimiter()
+	}
+	return o
+}
+
+// unlimited returns a copy of o without the rate limiter, for requests to
+// hosts other than oauth.reddit.com, whose budget is not the OAuth client's.
+func (o options) unlimited() options {
+	o.limiter = nil
+	return o
+}
+
+// WithBaseURL sets the URL used in place of https://www.reddit.com.
+func WithBaseURL(u string) Option {
+	return func(o *options) { o.www = strings.TrimSuffix(u, "/") }
+}
+
+// WithOAuthURL sets the URL used in place of https://oauth.reddit.com.
+func WithOAuthURL(u string) Option {
+	return func(o *options) { o.oauth = strings.TrimSuffix(u, "/") }
+}
+
+// WithAuthURL sets the URL hosting the OAuth authorize and access token
+// endpoints. It defaults to the base URL.
+func WithAuthURL(u string) Option {
+	return func(o *options) { o.auth = strings.TrimSuffix(u, "/") }
+}
+
+// WithHTTPClient sets the HTTP client used for every request of a session.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.client = c }
+}
+
+// WithRateLimiter sets the RateLimiter pacing the requests of a session.
+// By default every session has a RateLimiter of its own.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(o *options) { o.limiter = l }
+}
+
+func (o options) wwwURL() string {
+	if o.www == "" {
+		return defaultWWWURL
+	}
+	return o.www
+}
+
+func (o options) oauthURL() string {
+	if o.oauth == "" {
+		return defaultOAuthURL
+	}
+	return o.oauth
+}
+
+func (o options) authURL() string {
+	if o.auth == "" {
+		return o.wwwURL()
+	}
+	return o.auth
+}
+
+func (o options) httpClient() *http.Client {
+	if o.client == nil {
+		return http.DefaultClient
+	}
+	return o.client
+}
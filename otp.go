@@ -0,0 +1,89 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidOTP is returned, wrapped, when reddit rejects the login of an
+// account with two-factor authentication because the one-time password is
+// wrong or missing. Since the OAuth password grant reports a wrong password
+// and a wrong one-time password alike, LoginAuth returns it for both when a
+// one-time password is configured.
+var ErrInvalidOTP = errors.New("invalid or missing one-time password")
+
+// WithOTP sets the one-time password sent along with the password by
+// NewLoginSession and LoginAuth, for accounts with two-factor
+// authentication.
+func WithOTP(code string) Option {
+	return func(o *options) {
+		o.otp = func(time.Time) (string, error) { return code, nil }
+	}
+}
+
+// WithTOTPSecret is like WithOTP but generates the one-time password at
+// each login from the base32 encoded secret of the authenticator app.
+func WithTOTPSecret(secret string) Option {
+	return func(o *options) {
+		o.otp = func(t time.Time) (string, error) { return totp(secret, t) }
+	}
+}
+
+// password returns password in reddit's "password:otp" format if a
+// one-time password is configured.
+func (o options) password(password string) (string, error) {
+	if o.otp == nil {
+		return password, nil
+	}
+	code, err := o.otp(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return password + ":" + code, nil
+}
+
+// totp returns the RFC 6238 one-time password of secret at t, using the
+// 30 second steps and 6 digits of authenticator apps.
+func totp(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(t.Unix()/30))
+	h := hmac.New(sha1.New, key)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
+
+// otpError wraps the errors reddit reports for a wrong or missing one-time
+// password with ErrInvalidOTP. The invalid_grant error of the OAuth
+// password grant only counts if a one-time password was sent.
+func (o options) otpError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.Code == "WRONG_OTP", apiErr.Code == "TWO_FA_REQUIRED", apiErr.Field == "otp",
+		apiErr.Code == "invalid_grant" && o.otp != nil:
+		return fmt.Errorf("%w: %w", ErrInvalidOTP, err)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// Test vectors of RFC 6238, truncated to 6 digits.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := totp(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("totp(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+
+	if _, err := totp("not base32!", time.Now()); err == nil {
+		t.Error("totp() accepted an invalid secret")
+	}
+}
+
+func TestLoginOTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("passwd") != "hunter2:123456" {
+			fmt.Fprintln(w, `{"json": {"errors": [["WRONG_OTP", "wrong otp", "otp"]]}}`)
+			return
+		}
+		fmt.Fprintln(w, `{"json": {"errors": [], "data": {"modhash": "mh"}}}`)
+	}))
+	defer server.Close()
+
+	if _, err := NewLoginSession("user", "hunter2", "agent", WithBaseURL(server.URL), WithOTP("123456")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewLoginSession("user", "hunter2", "agent", WithBaseURL(server.URL), WithOTP("000000"))
+	if !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("NewLoginSession() returned %v, want ErrInvalidOTP", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "WRONG_OTP" {
+		t.Fatalf("NewLoginSession() returned %v, want the APIError", err)
+	}
+}
+
+func TestLoginAuthOTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.FormValue("password") {
+		case "hunter2:123456":
+			fmt.Fprintln(w, `{"access_token": "a", "token_type": "bearer", "expires_in": 3600, "scope": "*"}`)
+		case "hunter2:000000":
+			// Reddit answers a bad password grant with a 200.
+			fmt.Fprintln(w, `{"error": "invalid_grant"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"error": "invalid_grant"}`)
+		}
+	}))
+	defer server.Close()
+
+	login := func(opts ...Option) error {
+		o, err := NewOAuthSession("id", "secret", "agent", "", append(opts, WithAuthURL(server.URL))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o.LoginAuth("user", "hunter2")
+	}
+
+	if err := login(WithOTP("123456")); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"000000", "999999"} {
+		err := login(WithOTP(code))
+		var apiErr *APIError
+		if !errors.Is(err, ErrInvalidOTP) || !errors.As(err, &apiErr) || apiErr.Code != "invalid_grant" {
+			t.Fatalf("LoginAuth() with OTP %s returned %v, want ErrInvalidOTP", code, err)
+		}
+	}
+	if err := login(); err == nil || errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("LoginAuth() without OTP returned %v, want a plain error", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Pool manages the OAuthSessions of several reddit accounts, keyed by
+// username. The sessions of a Pool sharing a client ID also share a
+// RateLimiter, since reddit's rate limits apply per client. A Pool is safe
+// for concurrent use.
+type Pool struct {
+	mu       sync.Mutex
+	sessions map[string]*OAuthSession
+	names    []string
+	next     int
+	limiters map[string]*RateLimiter
+}
+
+// NewPool returns an empty Pool.
+func NewPool() *Pool {
+	return &Pool{
+		sessions: make(map[string]*OAuthSession),
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+// RateLimiter returns the RateLimiter shared by the sessions of the given
+// client ID, to create them with:
+//
+//	o, err := geddit.NewOAuthSession(clientID, secret, agent, redirect,
+//		geddit.WithRateLimiter(pool.RateLimiter(clientID)))
+func (p *Pool) RateLimiter(clientID string) *RateLimiter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l, ok := p.limiters[clientID]
+	if !ok {
+		l = NewRateLimiter()
+		p.limiters[clientID] = l
+	}
+	return l
+}
+
+// Add adds the authenticated session o of the given account, replacing
+// any session previously added for it. Unless it is the first session of
+// its client ID, whose RateLimiter the pool then shares, o must have been
+// created WithRateLimiter(p.RateLimiter(clientID)).
+func (p *Pool) Add(username string, o *OAuthSession) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clientID := o.OAuthConfig.ClientID
+	l, ok := p.limiters[clientID]
+	if !ok {
+		p.limiters[clientID] = o.opts.limiter
+	} else if l != o.opts.limiter {
+		return fmt.Errorf("session of %s does not share the RateLimiter of client %s", username, clientID)
+	}
+
+	if _, ok := p.sessions[username]; !ok {
+		p.names = append(p.names, username)
+	}
+	p.sessions[username] = o
+	return nil
+}
+
+// Remove removes the session of the given account.
+func (p *Pool) Remove(username string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.sessions[username]; !ok {
+		return
+	}
+	delete(p.sessions, username)
+	for i, name := range p.names {
+		if name == username {
+			p.names = append(p.names[:i], p.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Session returns the session of the given account, if any.
+func (p *Pool) Session(username string) (*OAuthSession, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	o, ok := p.sessions[username]
+	return o, ok
+}
+
+// Usernames returns the accounts of the pool in the order they were added.
+func (p *Pool) Usernames() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.names...)
+}
+
+// Next returns the sessions of the pool in turn, e.g. to spread read-only
+// requests across accounts. It returns nil if the pool is empty.
+func (p *Pool) Next() *OAuthSession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.names) == 0 {
+		return nil
+	}
+	p.next %= len(p.names)
+	o := p.sessions[p.names[p.next]]
+	p.next++
+	return o
+}
+
+// Refresh makes sure the token of every session is valid, refreshing the
+// expired ones. Sessions failing to refresh are kept, the errors are
+// returned joined.
+func (p *Pool) Refresh() error {
+	return p.RefreshContext(context.Background())
+}
+
+// RefreshContext is like Refresh but with a context.
+func (p *Pool) RefreshContext(ctx context.Context) error {
+	var errs []error
+	for _, username := range p.Usernames() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		o, ok := p.Session(username)
+		if !ok {
+			continue
+		}
+		if _, err := o.TokenContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", username, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool()
+	newSession := func(clientID string, opts ...Option) *OAuthSession {
+		o, err := NewOAuthSession(clientID, "secret", "agent", "", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.SetToken(&oauth2.Token{AccessToken: clientID, Expiry: time.Now().Add(time.Hour)})
+		return o
+	}
+
+	if p.Next() != nil {
+		t.Fatal("Next() of an empty pool returned a session")
+	}
+
+	a := newSession("bots", WithRateLimiter(p.RateLimiter("bots")))
+	b := newSession("bots", WithRateLimiter(p.RateLimiter("bots")))
+	c := newSession("other")
+	for _, s := range []struct {
+		name string
+		o    *OAuthSession
+	}{{"a", a}, {"b", b}, {"c", c}} {
+		if err := p.Add(s.name, s.o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a.RateLimiter() != b.RateLimiter() || p.RateLimiter("bots") != a.RateLimiter() {
+		t.Error("sessions of the same client do not share a RateLimiter")
+	}
+	if a.RateLimiter() == c.RateLimiter() || p.RateLimiter("other") != c.RateLimiter() {
+		t.Error("sessions of different clients share a RateLimiter")
+	}
+	if err := p.Add("d", newSession("bots")); err == nil {
+		t.Error("Add() accepted a session with its own RateLimiter")
+	}
+
+	for i, want := range []*OAuthSession{a, b, c, a} {
+		if p.Next() != want {
+			t.Errorf("Next() call %d returned the wrong session", i)
+		}
+	}
+
+	p.Remove("b")
+	if _, ok := p.Session("b"); ok {
+		t.Error("Remove() kept the session")
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(p.Usernames(), want) {
+		t.Errorf("Usernames() = %v, want %v", p.Usernames(), want)
+	}
+	if err := p.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolRefreshContext(t *testing.T) {
+	// The token endpoint hangs until the client gives up or the test ends.
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	o, err := NewOAuthSession("id", "secret", "agent", "", WithAuthURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetToken(&oauth2.Token{AccessToken: "old", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})
+	p := NewPool()
+	if err := p.Add("a", o); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.RefreshContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RefreshContext() returned %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("RefreshContext() ignored its context for %v", d)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces requests to fit the budget reddit reports in the
+// X-Ratelimit-* headers of its responses. Reddit accounts the budget per
+// OAuth client ID, so sessions sharing a client ID should share a
+// RateLimiter via WithRateLimiter.
+type RateLimiter struct {
+	mu        sync.Mutex
+	remaining float64
+	used      float64
+	reset     time.Time
+	next      time.Time
+}
+
+// RateBudget is a snapshot of the request budget reported by reddit.
+type RateBudget struct {
+	Remaining float64
+	Used      float64
+	Reset     time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that does not delay any request
+// until it has seen the headers of a response.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{}
+}
+
+// Budget returns the most recently reported budget.
+func (r *RateLimiter) Budget() RateBudget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return RateBudget{
+		Remaining: r.remaining,
+		Used:      r.used,
+		Reset:     r.reset,
+	}
+}
+
+// Wait blocks until the next request may be sent or ctx is done.
+// Requests are spread evenly over what is left of the current window.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	r.mu.Lock()
+	now := time.Now()
+	var at time.Time
+	switch {
+	case r.reset.IsZero() || !now.Before(r.reset):
+		// The window is unknown or over: nothing to pace against.
+	case r.remaining < 1:
+		at = r.reset
+	default:
+		at = r.next
+		if at.Before(now) {
+			at = now
+		}
+		r.next = at.Add(time.Duration(float64(r.reset.Sub(now)) / r.remaining))
+		r.remaining--
+	}
+	r.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Update records the budget reported in the headers of a response.
+// Responses without X-Ratelimit-* headers are ignored.
+func (r *RateLimiter) Update(h http.Header) {
+	remaining, err := strconv.ParseFloat(h.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseFloat(h.Get("X-Ratelimit-Reset"), 64)
+	if err != nil {
+		return
+	}
+	used, _ := strconv.ParseFloat(h.Get("X-Ratelimit-Used"), 64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remaining = remaining
+	r.used = used
+	r.reset = time.Now().Add(time.Duration(reset * float64(time.Second)))
+}
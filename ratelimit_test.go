@@ -0,0 +1,73 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	r := NewRateLimiter()
+	r.Update(http.Header{
+		"X-Ratelimit-Remaining": {"50.0"},
+		"X-Ratelimit-Used":      {"550"},
+		"X-Ratelimit-Reset":     {"1"},
+	})
+
+	b := r.Budget()
+	if b.Remaining != 50 || b.Used != 550 {
+		t.Fatalf("Budget() returned unexpected budget: %+v", b)
+	}
+
+	// 50 requests left in one second: the second request waits ~20ms.
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := r.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Fatalf("Wait() did not pace requests, took %v", d)
+	}
+}
+
+func TestRateLimiterExhausted(t *testing.T) {
+	r := NewRateLimiter()
+	r.Update(http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {"60"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait() returned unexpected error: %v", err)
+	}
+}
+
+func TestRateLimiterOAuthWWW(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", "60")
+		w.Write([]byte(`{"data": {"children": []}}`))
+	}))
+	defer server.Close()
+
+	l := NewRateLimiter()
+	o := &OAuthSession{
+		Client: server.Client(),
+		opts:   newOptions([]Option{WithBaseURL(server.URL), WithOAuthURL(server.URL), WithRateLimiter(l)}),
+	}
+	if _, err := o.SubredditComments("golang", ListingOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if b := l.Budget(); !b.Reset.IsZero() {
+		t.Fatalf("a www.reddit.com response updated the OAuth budget: %+v", b)
+	}
+}
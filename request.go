@@ -6,10 +6,11 @@ package geddit
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type request struct {
@@ -17,9 +18,20 @@ type request struct {
 	values    *url.Values
 	cookie    *http.Cookie
 	useragent string
+	opts      options
 }
 
-func (r request) getResponse() (*bytes.Buffer, error) {
+func (r request) getResponse(ctx context.Context) (*bytes.Buffer, error) {
+	_, body, err := r.response(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(body), nil
+}
+
+// response is like getResponse but also returns the HTTP status code, for
+// callers that check the body themselves.
+func (r request) response(ctx context.Context) (int, []byte, error) {
 	// Determine the HTTP action.
 	var action, finalurl string
 	if r.values == nil {
@@ -31,9 +43,9 @@ func (r request) getResponse() (*bytes.Buffer, error) {
 	}
 
 	// Create a request and add the proper headers.
-	req, err := http.NewRequest(action, finalurl, nil)
+	req, err := http.NewRequestWithContext(ctx, action, finalurl, nil)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 	if r.cookie != nil {
 		req.AddCookie(r.cookie)
@@ -41,19 +53,73 @@ func (r request) getResponse() (*bytes.Buffer, error) {
 	req.Header.Set("User-Agent", r.useragent)
 
 	// Handle the request
-	resp, err := http.DefaultClient.Do(req)
+	resp, respbytes, err := r.opts.do(r.opts.httpClient(), req)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+	if err := jsonError(resp.StatusCode, respbytes); err != nil {
+		return 0, nil, err
 	}
 
-	respbytes, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, respbytes, nil
+}
+
+// do sends req using client, pacing it with the session's rate limiter
+// and retrying it according to the session's retry policy, and returns the
+// response along with its body.
+func (o options) do(client *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, body, err := o.send(client, req)
+		if err == nil || !o.retry.shouldRetry(req, attempt, err) {
+			return resp, body, err
+		}
+
+		delay := o.retry.backoff(attempt, err)
+		if o.retry.OnRetry != nil {
+			o.retry.OnRetry(RetryEvent{Request: req, Attempt: attempt, Err: err, Delay: delay})
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, nil, ctx.Err()
+		case <-t.C:
+		}
+
+		// Rewind the body for the next attempt.
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+// send makes a single attempt at req.
+func (o options) send(client *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	if o.limiter != nil {
+		if err := o.limiter.Wait(req.Context()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if o.limiter != nil {
+		o.limiter.Update(resp.Header)
 	}
 
-	return bytes.NewBuffer(respbytes), nil
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := responseError(resp, body); err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
 }
@@ -0,0 +1,103 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with a transient error
+// (HTTP 429, 500, 502, 503 or 504) are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the base delay before the first retry. It doubles for
+	// every following retry, with jitter applied.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	// RetryPOST enables retries of POST requests such as Vote or Save.
+	// Only GET requests are retried otherwise.
+	RetryPOST bool
+	// OnRetry, if set, is called before each retry.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a retry about to be made.
+type RetryEvent struct {
+	// Request is the request that failed.
+	Request *http.Request
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	// Err is the error the attempt failed with.
+	Err error
+	// Delay is how long is waited before the next attempt.
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy retries GET requests up to three times in total. It is
+// the RetryPolicy of sessions created without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// WithRetryPolicy sets the RetryPolicy of a session in place of
+// DefaultRetryPolicy. WithRetryPolicy(RetryPolicy{}) disables retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) { o.retry = p }
+}
+
+// shouldRetry reports whether a request may be attempted again after
+// failing with err on the given attempt.
+func (p RetryPolicy) shouldRetry(req *http.Request, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if req.Method != "GET" && !(req.Method == "POST" && p.RetryPOST) {
+		return false
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retrying after the given attempt.
+// A Retry-After given by reddit takes precedence, within MaxBackoff.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if p.MaxBackoff > 0 {
+			return min(apiErr.RetryAfter, p.MaxBackoff)
+		}
+		return apiErr.RetryAfter
+	}
+
+	d := p.MinBackoff << uint(attempt-1)
+	if d <= 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Use "equal jitter": half fixed, half random.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
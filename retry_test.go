@@ -0,0 +1,79 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"name": "aggrolite"}`)
+	}))
+	defer server.Close()
+
+	var retries []RetryEvent
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		OnRetry:     func(e RetryEvent) { retries = append(retries, e) },
+	}
+	o := &OAuthSession{
+		Client: server.Client(),
+		opts:   newOptions([]Option{WithOAuthURL(server.URL), WithRetryPolicy(policy)}),
+	}
+
+	me, err := o.Me()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Name != "aggrolite" {
+		t.Fatalf("Me() returned unexpected name: %s", me.Name)
+	}
+	if len(retries) != 2 || retries[1].Attempt != 2 {
+		t.Fatalf("unexpected retries: %+v", retries)
+	}
+
+	// POST requests are not retried unless enabled.
+	requests, retries = 0, nil
+	if err := o.Vote(Submission{FullID: "t3_1"}, UpVote); err == nil {
+		t.Fatal("Vote() succeeded after a 503")
+	}
+	if requests != 1 {
+		t.Fatalf("Vote() was attempted %d times", requests)
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	get := httptest.NewRequest("GET", "/api/v1/me", nil)
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable}
+
+	if p := newOptions(nil).retry; !p.shouldRetry(get, 1, unavailable) || p.MaxAttempts != DefaultRetryPolicy.MaxAttempts {
+		t.Fatalf("sessions do not retry by default: %+v", p)
+	}
+	if p := newOptions([]Option{WithRetryPolicy(RetryPolicy{})}).retry; p.shouldRetry(get, 1, unavailable) {
+		t.Fatal("WithRetryPolicy(RetryPolicy{}) did not disable retries")
+	}
+
+	// A Retry-After beyond MaxBackoff is capped.
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Hour}
+	if d := DefaultRetryPolicy.backoff(1, limited); d != DefaultRetryPolicy.MaxBackoff {
+		t.Fatalf("backoff() returned %v, want %v", d, DefaultRetryPolicy.MaxBackoff)
+	}
+	limited.RetryAfter = 5 * time.Second
+	if d := DefaultRetryPolicy.backoff(1, limited); d != 5*time.Second {
+		t.Fatalf("backoff() returned %v, want 5s", d)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrInsufficientScope is returned, wrapped, by the methods of an
+// OAuthSession whose token was not granted the OAuth scope they require.
+// Such calls fail before any request is sent.
+var ErrInsufficientScope = errors.New("insufficient OAuth scope")
+
+// Scope describes an OAuth scope as listed by /api/v1/scopes.
+type Scope struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// scopeSet holds the scopes granted to the token of a session. Tokens
+// that do not tell their scopes, e.g. restored from a TokenStore which
+// dropped them, leave nothing enforced.
+type scopeSet struct {
+	mu      sync.Mutex
+	known   bool
+	granted map[string]bool
+}
+
+func newScopeSet(t *oauth2.Token) *scopeSet {
+	s := new(scopeSet)
+	s.update(t)
+	return s
+}
+
+// update records the scopes of the token response t, if it lists any.
+func (s *scopeSet) update(t *oauth2.Token) {
+	if s == nil || t == nil {
+		return
+	}
+	raw, ok := t.Extra("scope").(string)
+	if !ok {
+		return
+	}
+
+	granted := make(map[string]bool)
+	for _, scope := range strings.FieldsFunc(raw, func(r rune) bool { return r == ' ' || r == ',' }) {
+		granted[scope] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known = true
+	s.granted = granted
+}
+
+// has reports whether scope was granted. It also reports true if the
+// granted scopes are unknown.
+func (s *scopeSet) has(scope string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.known || s.granted["*"] || s.granted[scope]
+}
+
+// list returns the granted scopes in order, or nil if they are unknown.
+func (s *scopeSet) list() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known {
+		return nil
+	}
+	scopes := make([]string, 0, len(s.granted))
+	for scope := range s.granted {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// Scopes returns the OAuth scopes granted to the token of the session, as
+// told by the token response. It returns nil if they are unknown, e.g.
+// before authentication or for tokens restored from a TokenStore which
+// does not keep them.
+func (o *OAuthSession) Scopes() []string {
+	return o.scopes.list()
+}
+
+// HasScope reports whether the token of the session was granted scope.
+// It reports true as well if the granted scopes are unknown.
+func (o *OAuthSession) HasScope(scope string) bool {
+	return o.scopes.has(scope)
+}
+
+// requireScope returns an error wrapping ErrInsufficientScope unless scope
+// is empty or granted.
+func (o *OAuthSession) requireScope(scope string) error {
+	if scope == "" || o.scopes.has(scope) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q required", ErrInsufficientScope, scope)
+}
+
+// ScopeDescriptions returns the descriptions of the given OAuth scopes, or
+// of all scopes if none is given, keyed by ID.
+func (o *OAuthSession) ScopeDescriptions(scopes ...string) (map[string]Scope, error) {
+	return o.ScopeDescriptionsContext(context.Background(), scopes...)
+}
+
+// ScopeDescriptionsContext is like ScopeDescriptions but with a context.
+func (o *OAuthSession) ScopeDescriptionsContext(ctx context.Context, scopes ...string) (map[string]Scope, error) {
+	link := o.opts.oauthURL() + "/api/v1/scopes"
+	if len(scopes) > 0 {
+		link += "?" + url.Values{"scopes": {strings.Join(scopes, ",")}}.Encode()
+	}
+
+	var s map[string]Scope
+	if err := o.getBody(ctx, "", link, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
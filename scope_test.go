@@ -0,0 +1,53 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestScopes(t *testing.T) {
+	server, o := testTools(200, `{"name": "aggrolite"}`)
+	defer server.Close()
+
+	if o.Scopes() != nil || !o.HasScope("save") {
+		t.Fatal("unknown scopes should not be enforced")
+	}
+
+	tok := (&oauth2.Token{AccessToken: "a"}).WithExtra(map[string]interface{}{"scope": "read identity"})
+	o.scopes = newScopeSet(tok)
+	if got, want := o.Scopes(), []string{"identity", "read"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Scopes() = %v, want %v", got, want)
+	}
+
+	if _, err := o.Me(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Save(&Submission{FullID: "t3_abc"}, ""); !errors.Is(err, ErrInsufficientScope) {
+		t.Fatalf("Save() returned %v, want ErrInsufficientScope", err)
+	}
+
+	o.scopes.update((&oauth2.Token{AccessToken: "b"}).WithExtra(map[string]interface{}{"scope": "*"}))
+	if !o.HasScope("save") {
+		t.Fatal("the * scope should grant every scope")
+	}
+}
+
+func TestScopeDescriptions(t *testing.T) {
+	server, o := testTools(200, `{"read": {"id": "read", "name": "Read Content", "description": "Access posts and comments through my account."}}`)
+	defer server.Close()
+
+	s, err := o.ScopeDescriptions("read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s["read"].Name != "Read Content" {
+		t.Fatalf("unexpected scope description: %+v", s["read"])
+	}
+}
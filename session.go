@@ -5,6 +5,7 @@
 package geddit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -17,170 +18,189 @@ import (
 // without logging into an account.
 type Session struct {
 	useragent string
+	opts      options
 }
 
 // NewSession creates a new unauthenticated session to reddit.com.
-func NewSession(useragent string) *Session {
+func NewSession(useragent string, opts ...Option) *Session {
 	return &Session{
 		useragent: useragent,
+		opts:      newOptions(opts),
 	}
 }
 
+// RateLimiter returns the RateLimiter pacing the requests of the session.
+func (s Session) RateLimiter() *RateLimiter {
+	return s.opts.limiter
+}
+
 // DefaultFrontpage returns the submissions on the default reddit frontpage.
 func (s Session) DefaultFrontpage(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	return s.SubredditSubmissions("", sort, params)
+	return s.DefaultFrontpageContext(context.Background(), sort, params)
+}
+
+// DefaultFrontpageContext is like DefaultFrontpage but with a context.
+func (s Session) DefaultFrontpageContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.SubredditSubmissionsContext(ctx, "", sort, params)
+}
+
+// Frontpage returns the submissions on the default reddit frontpage, like
+// DefaultFrontpage.
+func (s Session) Frontpage(sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.FrontpageContext(context.Background(), sort, params)
+}
+
+// FrontpageContext is like Frontpage but with a context.
+func (s Session) FrontpageContext(ctx context.Context, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	return s.DefaultFrontpageContext(ctx, sort, params)
 }
 
 // SubredditSubmissions returns the submissions on the given subreddit.
 func (s Session) SubredditSubmissions(subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
-	v, err := query.Values(params)
+	return s.SubredditSubmissionsContext(context.Background(), subreddit, sort, params)
+}
+
+// SubredditSubmissionsContext is like SubredditSubmissions but with a context.
+func (s Session) SubredditSubmissionsContext(ctx context.Context, subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	l, err := s.getListing(ctx, s.subredditURL(subreddit, string(sort)), params)
 	if err != nil {
 		return nil, err
 	}
+	return l.submissions()
+}
 
-	baseUrl := "https://www.reddit.com"
-
-	// If subbreddit given, add to URL
-	if subreddit != "" {
-		baseUrl += "/r/" + subreddit
+// SubredditSubmissionsIterator returns a ListingIterator over the
+// submissions on the given subreddit.
+func (s Session) SubredditSubmissionsIterator(subreddit string, sort PopularitySort, params ListingOptions) *ListingIterator[*Submission] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return s.getListing(ctx, s.subredditURL(subreddit, string(sort)), params)
 	}
+	return newListingIterator(params, fetch, (*listing).submissions)
+}
 
-	redditUrl := fmt.Sprintf(baseUrl+"/%s.json?%s", sort, v.Encode())
+// SubmissionComments returns the comments on a submission given it's ID.
+func (s Session) SubmissionsComments(submissionID string) ([]*Comment, error) {
+	return s.SubmissionsCommentsContext(context.Background(), submissionID)
+}
 
-	req := request{
-		url:       redditUrl,
-		useragent: s.useragent,
-	}
-	body, err := req.getResponse()
+// SubmissionsCommentsContext is like SubmissionsComments but with a context.
+func (s Session) SubmissionsCommentsContext(ctx context.Context, submissionID string) ([]*Comment, error) {
+	t, err := s.ThreadContext(ctx, submissionID, DefaultPopularity, ListingOptions{})
 	if err != nil {
 		return nil, err
 	}
+	return t.Comments, nil
+}
 
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
-	}
+// Thread returns the submission with the given ID along with its tree of
+// comments.
+func (s Session) Thread(submissionID string, sort PopularitySort, params ListingOptions) (*Thread, error) {
+	return s.ThreadContext(context.Background(), submissionID, sort, params)
+}
 
-	r := new(Response)
-	err = json.NewDecoder(body).Decode(r)
+// ThreadContext is like Thread but with a context.
+func (s Session) ThreadContext(ctx context.Context, submissionID string, sort PopularitySort, params ListingOptions) (*Thread, error) {
+	v, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
-
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
+	if sort != "" {
+		v.Set("sort", string(sort))
 	}
 
-	return submissions, nil
-}
-
-// SubmissionComments returns the comments on a submission given it's ID.
-func (s Session) SubmissionsComments(submissionID string) ([]*Comment, error) {
-	redditURL := "https://www.reddit.com"
-	redditURL += "/comments/" + submissionID
-	redditURL += ".json"
-
 	req := request{
-		url:       redditURL,
+		url:       fmt.Sprintf("%s/comments/%s.json?%s", s.opts.wwwURL(), submissionID, v.Encode()),
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var comments interface{}
-	err = json.NewDecoder(body).Decode(&comments)
-	if err != nil {
+	var ls []listing
+	if err := json.NewDecoder(body).Decode(&ls); err != nil {
 		return nil, err
 	}
-	helper := new(helper)
-	helper.buildComments(comments)
-
-	return helper.comments, nil
+	return newThread(ls)
 }
 
 // AboutRedditor returns a Redditor for the given username.
 func (s Session) AboutRedditor(username string) (*Redditor, error) {
+	return s.AboutRedditorContext(context.Background(), username)
+}
+
+// AboutRedditorContext is like AboutRedditor but with a context.
+func (s Session) AboutRedditorContext(ctx context.Context, username string) (*Redditor, error) {
 	req := &request{
-		url:       fmt.Sprintf("https://www.reddit.com/user/%s/about.json", username),
+		url:       fmt.Sprintf("%s/user/%s/about.json", s.opts.wwwURL(), username),
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data Redditor
-	}
-	r := new(Response)
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	var t Thing
+	if err := json.NewDecoder(body).Decode(&t); err != nil {
 		return nil, err
 	}
-
-	return &r.Data, nil
+	return thingData[*Redditor](&t)
 }
 
 // AboutSubreddit returns a subreddit for the given subreddit name.
 func (s Session) AboutSubreddit(subreddit string) (*Subreddit, error) {
+	return s.AboutSubredditContext(context.Background(), subreddit)
+}
+
+// AboutSubredditContext is like AboutSubreddit but with a context.
+func (s Session) AboutSubredditContext(ctx context.Context, subreddit string) (*Subreddit, error) {
 	req := &request{
-		url:       fmt.Sprintf("https://www.reddit.com/r/%s/about.json", subreddit),
+		url:       fmt.Sprintf("%s/r/%s/about.json", s.opts.wwwURL(), subreddit),
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data Subreddit
-	}
-
-	r := new(Response)
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	var t Thing
+	if err := json.NewDecoder(body).Decode(&t); err != nil {
 		return nil, err
 	}
-
-	return &r.Data, nil
+	return thingData[*Subreddit](&t)
 }
 
 // Comments returns the comments for a given Submission.
-func (s Session) Comments(h *Submission) ([]*Comment, error) {
-	req := &request{
-		url:       fmt.Sprintf("https://www.reddit.com/comments/%s/.json", h.ID),
-		useragent: s.useragent,
-	}
-	body, err := req.getResponse()
-	if err != nil {
-		return nil, err
-	}
+func (s Session) Comments(h *Submission, sort PopularitySort, params ListingOptions) ([]*Comment, error) {
+	return s.CommentsContext(context.Background(), h, sort, params)
+}
 
-	r := json.NewDecoder(body)
-	var interf interface{}
-	if err = r.Decode(&interf); err != nil {
+// CommentsContext is like Comments but with a context.
+func (s Session) CommentsContext(ctx context.Context, h *Submission, sort PopularitySort, params ListingOptions) ([]*Comment, error) {
+	t, err := s.ThreadContext(ctx, h.ID, sort, params)
+	if err != nil {
 		return nil, err
 	}
-	helper := new(helper)
-	helper.buildComments(interf)
-
-	return helper.comments, nil
+	return t.Comments, nil
 }
 
 // CaptchaImage gets the png corresponding to the captcha iden and decodes it
 func (s Session) CaptchaImage(iden string) (image.Image, error) {
+	return s.CaptchaImageContext(context.Background(), iden)
+}
+
+// CaptchaImageContext is like CaptchaImage but with a context.
+func (s Session) CaptchaImageContext(ctx context.Context, iden string) (image.Image, error) {
 	req := &request{
-		url:       fmt.Sprintf("https://www.reddit.com/captcha/%s", iden),
+		url:       fmt.Sprintf("%s/captcha/%s", s.opts.wwwURL(), iden),
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
 
-	p, err := req.getResponse()
+	p, err := req.getResponse(ctx)
 
 	if err != nil {
 		return nil, err
@@ -197,119 +217,112 @@ func (s Session) CaptchaImage(iden string) (image.Image, error) {
 
 // SubredditComments gets all the new comments from a subreddit, returning them in a slice of Comment structs
 func (s Session) SubredditComments(subreddit string, params ListingOptions) ([]*Comment, error) {
-	v, err := query.Values(params)
+	return s.SubredditCommentsContext(context.Background(), subreddit, params)
+}
+
+// SubredditCommentsContext is like SubredditComments but with a context.
+func (s Session) SubredditCommentsContext(ctx context.Context, subreddit string, params ListingOptions) ([]*Comment, error) {
+	l, err := s.getListing(ctx, s.subredditURL(subreddit, "comments"), params)
 	if err != nil {
 		return nil, err
 	}
+	return l.comments()
+}
 
-	baseUrl := "https://www.reddit.com"
-
-	// If subbreddit given, add to URL
-	if subreddit != "" {
-		baseUrl += "/r/" + subreddit
+// SubredditCommentsIterator returns a ListingIterator over the new
+// comments of a subreddit.
+func (s Session) SubredditCommentsIterator(subreddit string, params ListingOptions) *ListingIterator[*Comment] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return s.getListing(ctx, s.subredditURL(subreddit, "comments"), params)
 	}
+	return newListingIterator(params, fetch, (*listing).comments)
+}
 
-	redditUrl := fmt.Sprintf(baseUrl+"/comments.json?%s", v.Encode())
-
-	req := request{
-		url:       redditUrl,
-		useragent: s.useragent,
-	}
+// RedditorComments returns a slice of Comments from a given Reddit user name.
+func (s Session) RedditorComments(username string, params ListingOptions) ([]*Comment, error) {
+	return s.RedditorCommentsContext(context.Background(), username, params)
+}
 
-	body, err := req.getResponse()
+// RedditorCommentsContext is like RedditorComments but with a context.
+func (s Session) RedditorCommentsContext(ctx context.Context, username string, params ListingOptions) ([]*Comment, error) {
+	l, err := s.getListing(ctx, s.redditorURL(username, "comments"), params)
 	if err != nil {
 		return nil, err
 	}
+	return l.comments()
+}
 
-	var comments interface{}
-	if err = json.NewDecoder(body).Decode(&comments); err != nil {
-		return nil, err
+// RedditorCommentsIterator returns a ListingIterator over the comments of
+// a given Reddit user name.
+func (s Session) RedditorCommentsIterator(username string, params ListingOptions) *ListingIterator[*Comment] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return s.getListing(ctx, s.redditorURL(username, "comments"), params)
 	}
-	helper := new(helper)
-	helper.buildComments(comments)
+	return newListingIterator(params, fetch, (*listing).comments)
+}
 
-	return helper.comments, nil
+// RedditorSubmissions returns a slice of Submissions from a given Reddit user name.
+func (s Session) RedditorSubmissions(username string, params ListingOptions) ([]*Submission, error) {
+	return s.RedditorSubmissionsContext(context.Background(), username, params)
 }
 
-// RedditorComments returns a slice of Comments from a given Reddit user name.
-func (s Session) RedditorComments(username string, params ListingOptions) ([]*Comment, error) {
-	v, err := query.Values(params)
+// RedditorSubmissionsContext is like RedditorSubmissions but with a context.
+func (s Session) RedditorSubmissionsContext(ctx context.Context, username string, params ListingOptions) ([]*Submission, error) {
+	l, err := s.getListing(ctx, s.redditorURL(username, "submitted"), params)
 	if err != nil {
 		return nil, err
 	}
+	return l.submissions()
+}
 
-	baseUrl := "https://www.reddit.com"
-
-	// If username given, add to URL
-	if username != "" {
-		baseUrl += "/user/" + username
-	}
-
-	redditUrl := fmt.Sprintf(baseUrl+"/comments.json?%s", v.Encode())
-
-	req := request{
-		url:       redditUrl,
-		useragent: s.useragent,
+// RedditorSubmissionsIterator returns a ListingIterator over the
+// submissions of a given Reddit user name.
+func (s Session) RedditorSubmissionsIterator(username string, params ListingOptions) *ListingIterator[*Submission] {
+	fetch := func(ctx context.Context, params ListingOptions) (*listing, error) {
+		return s.getListing(ctx, s.redditorURL(username, "submitted"), params)
 	}
+	return newListingIterator(params, fetch, (*listing).submissions)
+}
 
-	body, err := req.getResponse()
-	if err != nil {
-		return nil, err
+// subredditURL returns the URL of a listing on the given subreddit,
+// or on the frontpage if subreddit is empty.
+func (s Session) subredditURL(subreddit, listing string) string {
+	baseURL := s.opts.wwwURL()
+	if subreddit != "" {
+		baseURL += "/r/" + subreddit
 	}
+	return fmt.Sprintf("%s/%s.json", baseURL, listing)
+}
 
-	var comments interface{}
-	if err = json.NewDecoder(body).Decode(&comments); err != nil {
-		return nil, err
+// redditorURL returns the URL of a listing of the given user.
+func (s Session) redditorURL(username, listing string) string {
+	baseURL := s.opts.wwwURL()
+	if username != "" {
+		baseURL += "/user/" + username
 	}
-	helper := new(helper)
-	helper.buildComments(comments)
-
-	return helper.comments, nil
+	return fmt.Sprintf("%s/%s.json", baseURL, listing)
 }
 
-// RedditorSubmissions returns a slice of Submissions from a given Reddit user name.
-func (s Session) RedditorSubmissions(username string, params ListingOptions) ([]*Submission, error) {
+// getListing fetches the Listing at link.
+func (s Session) getListing(ctx context.Context, link string, params ListingOptions) (*listing, error) {
 	v, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
 
-	baseUrl := "https://www.reddit.com"
-
-	// If username given, add to URL
-	if username != "" {
-		baseUrl += "/user/" + username
-	}
-
-	redditUrl := fmt.Sprintf(baseUrl+"/submitted.json?%s", v.Encode())
-
 	req := request{
-		url:       redditUrl,
+		url:       link + "?" + v.Encode(),
 		useragent: s.useragent,
+		opts:      s.opts,
 	}
-	body, err := req.getResponse()
+	body, err := req.getResponse(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	type Response struct {
-		Data struct {
-			Children []struct {
-				Data *Submission
-			}
-		}
-	}
-
-	r := new(Response)
-	err = json.NewDecoder(body).Decode(r)
-	if err != nil {
+	l := new(listing)
+	if err := json.NewDecoder(body).Decode(l); err != nil {
 		return nil, err
 	}
-
-	submissions := make([]*Submission, len(r.Data.Children))
-	for i, child := range r.Data.Children {
-		submissions[i] = child.Data
-	}
-
-	return submissions, nil
+	return l, nil
 }
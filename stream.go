@@ -0,0 +1,172 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// StreamSource is implemented by the sessions that can be streamed from.
+type StreamSource interface {
+	SubredditSubmissionsContext(ctx context.Context, subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error)
+	SubredditCommentsContext(ctx context.Context, subreddit string, params ListingOptions) ([]*Comment, error)
+}
+
+// StreamOptions configures StreamSubmissions and StreamComments.
+type StreamOptions struct {
+	// MinInterval and MaxInterval bound the delay between two polls. The
+	// delay shrinks towards MinInterval while new items keep coming and
+	// grows towards MaxInterval otherwise. They default to 1 and 16 seconds,
+	// and a MaxInterval below MinInterval is raised to MinInterval.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// SeenSize is the number of fullnames remembered to skip items that
+	// were already delivered. It defaults to 1000.
+	SeenSize int
+	// SkipExisting skips the items present when the stream starts.
+	SkipExisting bool
+	// OnError, if set, is called with the error of each failed poll.
+	// The stream keeps polling regardless.
+	OnError func(error)
+}
+
+func (o StreamOptions) withDefaults() StreamOptions {
+	if o.MinInterval <= 0 {
+		o.MinInterval = time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 16 * time.Second
+	}
+	if o.MaxInterval < o.MinInterval {
+		o.MaxInterval = o.MinInterval
+	}
+	if o.SeenSize <= 0 {
+		o.SeenSize = 1000
+	}
+	return o
+}
+
+// StreamSubmissions polls the new submissions of the given subreddits and
+// delivers each one once, oldest first, until ctx is done. The returned
+// channel is closed when the stream stops.
+func StreamSubmissions(ctx context.Context, src StreamSource, subreddits []string, opts StreamOptions) <-chan *Submission {
+	subreddit := strings.Join(subreddits, "+")
+	fetch := func(ctx context.Context) ([]*Submission, error) {
+		return src.SubredditSubmissionsContext(ctx, subreddit, NewSubmissions, ListingOptions{Limit: 100})
+	}
+	return stream(ctx, opts, fetch, func(s *Submission) string { return s.FullID })
+}
+
+// StreamComments polls the new comments of the given subreddits and
+// delivers each one once, oldest first, until ctx is done. The returned
+// channel is closed when the stream stops.
+func StreamComments(ctx context.Context, src StreamSource, subreddits []string, opts StreamOptions) <-chan *Comment {
+	subreddit := strings.Join(subreddits, "+")
+	fetch := func(ctx context.Context) ([]*Comment, error) {
+		return src.SubredditCommentsContext(ctx, subreddit, ListingOptions{Limit: 100})
+	}
+	return stream(ctx, opts, fetch, func(c *Comment) string { return c.FullID })
+}
+
+func stream[T any](ctx context.Context, opts StreamOptions, fetch func(context.Context) ([]T, error), fullname func(T) string) <-chan T {
+	opts = opts.withDefaults()
+	c := make(chan T)
+
+	go func() {
+		defer close(c)
+
+		seen := newSeenSet(opts.SeenSize)
+		interval := opts.MinInterval
+		first := true
+		for {
+			items, err := fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			var fresh []T
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+			} else {
+				// Listings are newest first.
+				for i := len(items) - 1; i >= 0; i-- {
+					if seen.add(fullname(items[i])) {
+						fresh = append(fresh, items[i])
+					}
+				}
+			}
+			if first && opts.SkipExisting {
+				fresh = nil
+			}
+			// The existing items are only known once a poll succeeded.
+			if err == nil {
+				first = false
+			}
+
+			for _, item := range fresh {
+				select {
+				case c <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(fresh) > 0 {
+				interval /= 2
+				if interval < opts.MinInterval {
+					interval = opts.MinInterval
+				}
+			} else {
+				interval *= 2
+				if interval > opts.MaxInterval {
+					interval = opts.MaxInterval
+				}
+			}
+
+			t := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return c
+}
+
+// seenSet remembers the most recently added fullnames.
+type seenSet struct {
+	set  map[string]struct{}
+	ring []string
+	next int
+}
+
+func newSeenSet(size int) *seenSet {
+	return &seenSet{
+		set:  make(map[string]struct{}, size),
+		ring: make([]string, size),
+	}
+}
+
+// add adds name to the set, evicting the oldest one if full, and reports
+// whether it was not already present.
+func (s *seenSet) add(name string) bool {
+	if _, ok := s.set[name]; ok {
+		return false
+	}
+	if old := s.ring[s.next]; old != "" {
+		delete(s.set, old)
+	}
+	s.ring[s.next] = name
+	s.next = (s.next + 1) % len(s.ring)
+	s.set[name] = struct{}{}
+	return true
+}
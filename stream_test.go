@@ -0,0 +1,111 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	_ StreamSource = Session{}
+	_ StreamSource = &OAuthSession{}
+)
+
+type fakeStreamSource struct {
+	polls [][]*Submission
+	// fail is the number of polls failing before polls are answered.
+	fail int
+}
+
+func (f *fakeStreamSource) SubredditSubmissionsContext(ctx context.Context, subreddit string, sort PopularitySort, params ListingOptions) ([]*Submission, error) {
+	if subreddit != "golang+rust" || sort != NewSubmissions {
+		panic("unexpected listing " + subreddit)
+	}
+	if f.fail > 0 {
+		f.fail--
+		return nil, errors.New("503 Service Unavailable")
+	}
+	if len(f.polls) == 0 {
+		return nil, nil
+	}
+	p := f.polls[0]
+	f.polls = f.polls[1:]
+	return p, nil
+}
+
+func (f *fakeStreamSource) SubredditCommentsContext(ctx context.Context, subreddit string, params ListingOptions) ([]*Comment, error) {
+	return nil, nil
+}
+
+func TestStreamSubmissions(t *testing.T) {
+	a, b, c := &Submission{FullID: "t3_a"}, &Submission{FullID: "t3_b"}, &Submission{FullID: "t3_c"}
+	src := &fakeStreamSource{polls: [][]*Submission{{b, a}, {c, b, a}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := StreamSubmissions(ctx, src, []string{"golang", "rust"}, StreamOptions{MinInterval: time.Millisecond})
+
+	for _, want := range []string{"t3_a", "t3_b", "t3_c"} {
+		if got := (<-stream).FullID; got != want {
+			t.Fatalf("stream delivered %s, want %s", got, want)
+		}
+	}
+
+	cancel()
+	for range stream {
+	}
+}
+
+func TestStreamSkipExistingAfterError(t *testing.T) {
+	a, b, c := &Submission{FullID: "t3_a"}, &Submission{FullID: "t3_b"}, &Submission{FullID: "t3_c"}
+	src := &fakeStreamSource{polls: [][]*Submission{{b, a}, {c, b, a}}, fail: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := StreamSubmissions(ctx, src, []string{"golang", "rust"}, StreamOptions{MinInterval: time.Millisecond, SkipExisting: true})
+
+	if got := (<-stream).FullID; got != "t3_c" {
+		t.Fatalf("stream delivered %s, want t3_c", got)
+	}
+
+	cancel()
+	for range stream {
+	}
+}
+
+func TestStreamOptionsDefaults(t *testing.T) {
+	for _, tt := range []struct {
+		in       StreamOptions
+		min, max time.Duration
+	}{
+		{StreamOptions{}, time.Second, 16 * time.Second},
+		{StreamOptions{MinInterval: 5 * time.Second}, 5 * time.Second, 16 * time.Second},
+		{StreamOptions{MinInterval: time.Minute}, time.Minute, time.Minute},
+		{StreamOptions{MaxInterval: time.Minute}, time.Second, time.Minute},
+	} {
+		o := tt.in.withDefaults()
+		if o.MinInterval != tt.min || o.MaxInterval != tt.max {
+			t.Errorf("%+v: got intervals %v and %v, want %v and %v", tt.in, o.MinInterval, o.MaxInterval, tt.min, tt.max)
+		}
+	}
+}
+
+func TestSeenSet(t *testing.T) {
+	s := newSeenSet(2)
+	for _, name := range []string{"a", "b", "c"} {
+		if !s.add(name) {
+			t.Fatalf("add(%q) reported a duplicate", name)
+		}
+	}
+	if s.add("c") {
+		t.Fatal("add(\"c\") did not report a duplicate")
+	}
+	if !s.add("a") {
+		t.Fatal("add(\"a\") was not evicted")
+	}
+}
@@ -35,6 +35,32 @@ type Submission struct {
 	IsSaved       bool    `json:"saved"`
 	BannedBy      *string `json:"banned_by"`
 	LinkFlairText string  `json:"link_flair_text"`
+
+	AuthorFullID        string  `json:"author_fullname"`
+	Edited              Edited  `json:"edited"`
+	IsStickied          bool    `json:"stickied"`
+	IsLocked            bool    `json:"locked"`
+	IsSpoiler           bool    `json:"spoiler"`
+	IsArchived          bool    `json:"archived"`
+	Distinguished       string  `json:"distinguished"`
+	UpvoteRatio         float64 `json:"upvote_ratio"`
+	LinkFlairTemplateID string  `json:"link_flair_template_id"`
+	RemovedByCategory   string  `json:"removed_by_category"`
+	NumCrossposts       int     `json:"num_crossposts"`
+	TotalAwards         int     `json:"total_awards_received"`
+
+	// CrosspostParents holds the crossposted submission, if any.
+	CrosspostParents []*Submission `json:"crosspost_parent_list"`
+	// Media and SecureMedia describe the embedded video or oEmbed of the
+	// submission, if any.
+	Media       *Media   `json:"media"`
+	SecureMedia *Media   `json:"secure_media"`
+	Preview     *Preview `json:"preview"`
+	// GalleryData orders the images of a gallery, which MediaMetadata
+	// describes by media ID.
+	GalleryData   *GalleryData              `json:"gallery_data"`
+	MediaMetadata map[string]*MediaMetadata `json:"media_metadata"`
+	PollData      *PollData                 `json:"poll_data"`
 }
 
 func (h Submission) voteID() string   { return h.FullID }
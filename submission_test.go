@@ -0,0 +1,144 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const galleryJSON = `{
+	"name": "t3_abc",
+	"author_fullname": "t2_xyz",
+	"edited": 1600000000.5,
+	"stickied": true,
+	"locked": false,
+	"distinguished": null,
+	"upvote_ratio": 0.97,
+	"link_flair_template_id": "0c6d7b84",
+	"removed_by_category": null,
+	"num_crossposts": 2,
+	"total_awards_received": 1,
+	"crosspost_parent_list": [{"name": "t3_parent", "edited": false}],
+	"media": null,
+	"secure_media": {"reddit_video": {"fallback_url": "https://v.redd.it/x/DASH_720.mp4", "duration": 12, "is_gif": false}},
+	"preview": {"enabled": true, "images": [{"id": "img", "source": {"url": "https://preview.redd.it/a.jpg?s=1&amp;t=2", "width": 640, "height": 480}, "resolutions": [], "variants": {}}]},
+	"gallery_data": {"items": [{"id": 1, "media_id": "m1", "caption": "first", "outbound_url": "https://example.com"}]},
+	"media_metadata": {"m1": {"status": "valid", "e": "Image", "m": "image/jpg", "s": {"u": "https://preview.redd.it/m1.jpg", "x": 800, "y": 600}, "p": [], "id": "m1"}},
+	"poll_data": {"total_vote_count": 10, "voting_end_timestamp": 1600086400000, "options": [{"id": "1", "text": "yes", "vote_count": 7}]}
+}`
+
+func TestSubmissionJSON(t *testing.T) {
+	var s Submission
+	if err := json.Unmarshal([]byte(galleryJSON), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Edited.Edited || !s.Edited.Time.Equal(time.Unix(1600000000, 5e8)) {
+		t.Errorf("unexpected edited: %+v", s.Edited)
+	}
+	if !s.IsStickied || s.UpvoteRatio != 0.97 || s.AuthorFullID != "t2_xyz" || s.NumCrossposts != 2 {
+		t.Errorf("unexpected submission: %+v", s)
+	}
+	if len(s.CrosspostParents) != 1 || s.CrosspostParents[0].FullID != "t3_parent" || s.CrosspostParents[0].Edited.Edited {
+		t.Errorf("unexpected crosspost parents: %+v", s.CrosspostParents)
+	}
+	if s.Media != nil || s.SecureMedia.RedditVideo.Duration != 12 {
+		t.Errorf("unexpected media: %+v, %+v", s.Media, s.SecureMedia)
+	}
+	if s.Preview.Images[0].Source.Width != 640 {
+		t.Errorf("unexpected preview: %+v", s.Preview)
+	}
+	item := s.GalleryData.Items[0]
+	if m := s.MediaMetadata[item.MediaID]; m == nil || m.Source.URL != "https://preview.redd.it/m1.jpg" || item.Caption != "first" {
+		t.Errorf("unexpected gallery: %+v, %+v", s.GalleryData, s.MediaMetadata)
+	}
+	if s.PollData.Options[0].VoteCount != 7 {
+		t.Errorf("unexpected poll: %+v", s.PollData)
+	}
+}
+
+func TestEdited(t *testing.T) {
+	for _, raw := range []string{"false", "true", "1600000000"} {
+		var e Edited
+		if err := json.Unmarshal([]byte(raw), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Edited != (raw != "false") {
+			t.Errorf("Edited of %s = %v", raw, e.Edited)
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != raw {
+			t.Errorf("Edited of %s marshals to %s", raw, b)
+		}
+	}
+
+	var e Edited
+	if err := json.Unmarshal([]byte(`"soon"`), &e); err == nil {
+		t.Error("Edited accepted a string")
+	}
+}
+
+func TestNewSubmissionValues(t *testing.T) {
+	ns := NewLinkSubmission("golang", "Go 2", "https://go.dev", true, nil)
+	ns.FlairID, ns.FlairText, ns.NSFW = "0c6d7b84", "news", true
+	ns.EventStart = time.Date(2026, 1, 2, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	v := ns.values()
+	want := map[string]string{
+		"kind":        "link",
+		"url":         "https://go.dev",
+		"text":        "",
+		"flair_id":    "0c6d7b84",
+		"flair_text":  "news",
+		"nsfw":        "true",
+		"spoiler":     "false",
+		"event_start": "2026-01-02T11:00:00",
+		"event_end":   "",
+		"event_tz":    "UTC",
+	}
+	for k, w := range want {
+		if v.Get(k) != w {
+			t.Errorf("%s = %q, want %q", k, v.Get(k), w)
+		}
+	}
+
+	var r submitResponse
+	r.JSON.Data.ID = "t3_abc"
+	if s := r.submission(ns); s.ID != "abc" || s.FullID != "t3_abc" || !s.IsNSFW || s.LinkFlairText != "news" {
+		t.Errorf("unexpected submission: %+v", s)
+	}
+}
+
+func TestPollBounds(t *testing.T) {
+	options := func(n int) []string { return make([]string, n) }
+	for _, tt := range []struct {
+		options, days int
+		want          int
+		err           bool
+	}{
+		{2, 1, 1, false},
+		{6, 7, 7, false},
+		{3, 0, 3, false},
+		{1, 3, 0, true},
+		{7, 3, 0, true},
+		{2, -1, 0, true},
+		{2, 8, 0, true},
+	} {
+		ns := NewPollSubmission("golang", "title", "text", options(tt.options), tt.days, true)
+		p, err := ns.poll()
+		if (err != nil) != tt.err {
+			t.Errorf("%d options, %d days: got error %v", tt.options, tt.days, err)
+			continue
+		}
+		if err == nil && (p.Duration != tt.want || len(p.Options) != tt.options) {
+			t.Errorf("%d options, %d days: unexpected poll %+v", tt.options, tt.days, p)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// eventTimeFormat is the format of the event times of a submission, which
+// reddit reads in the event_tz time zone.
+const eventTimeFormat = "2006-01-02T15:04:05"
+
+// values returns the form of an /api/submit request for ns.
+func (ns *NewSubmission) values() url.Values {
+	p := ns.post()
+	v := url.Values{
+		"title":       {p.Title},
+		"sr":          {p.Subreddit},
+		"sendreplies": {strconv.FormatBool(p.SendReplies)},
+		"resubmit":    {strconv.FormatBool(ns.Resubmit)},
+		"nsfw":        {strconv.FormatBool(p.NSFW)},
+		"spoiler":     {strconv.FormatBool(p.Spoiler)},
+		"api_type":    {p.APIType},
+	}
+	if ns.Self {
+		v.Set("kind", "self")
+		v.Set("text", ns.Content)
+	} else {
+		v.Set("kind", "link")
+		v.Set("url", ns.Content)
+	}
+
+	for k, s := range map[string]string{
+		"flair_id":      p.FlairID,
+		"flair_text":    p.FlairText,
+		"collection_id": p.CollectionID,
+		"event_start":   p.EventStart,
+		"event_end":     p.EventEnd,
+		"event_tz":      p.EventTZ,
+	} {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	return v
+}
+
+// submitPost holds the fields shared by the JSON bodies of
+// /api/submit_gallery_post.json and /api/submit_poll_post.json.
+type submitPost struct {
+	APIType      string `json:"api_type"`
+	Subreddit    string `json:"sr"`
+	Title        string `json:"title"`
+	SendReplies  bool   `json:"sendreplies"`
+	NSFW         bool   `json:"nsfw"`
+	Spoiler      bool   `json:"spoiler"`
+	FlairID      string `json:"flair_id,omitempty"`
+	FlairText    string `json:"flair_text,omitempty"`
+	CollectionID string `json:"collection_id,omitempty"`
+	EventStart   string `json:"event_start,omitempty"`
+	EventEnd     string `json:"event_end,omitempty"`
+	EventTZ      string `json:"event_tz,omitempty"`
+}
+
+func (ns *NewSubmission) post() submitPost {
+	p := submitPost{
+		APIType:      "json",
+		Subreddit:    ns.Subreddit,
+		Title:        ns.Title,
+		SendReplies:  ns.SendReplies,
+		NSFW:         ns.NSFW,
+		Spoiler:      ns.Spoiler,
+		FlairID:      ns.FlairID,
+		FlairText:    ns.FlairText,
+		CollectionID: ns.CollectionID,
+	}
+	if !ns.EventStart.IsZero() {
+		p.EventStart = ns.EventStart.UTC().Format(eventTimeFormat)
+		p.EventTZ = "UTC"
+	}
+	if !ns.EventEnd.IsZero() {
+		p.EventEnd = ns.EventEnd.UTC().Format(eventTimeFormat)
+		p.EventTZ = "UTC"
+	}
+	return p
+}
+
+// pollPost is the JSON body of /api/submit_poll_post.json.
+type pollPost struct {
+	submitPost
+	Text     string   `json:"text"`
+	Options  []string `json:"options"`
+	Duration int      `json:"duration"`
+}
+
+// Bounds of a poll, as enforced by reddit.
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 6
+	maxPollDuration     = 7
+	defaultPollDuration = 3
+)
+
+// poll returns the body of a poll submission, or an error if reddit would
+// reject the poll.
+func (ns *NewSubmission) poll() (pollPost, error) {
+	if n := len(ns.PollOptions); n < minPollOptions || n > maxPollOptions {
+		return pollPost{}, fmt.Errorf("polls have %d to %d options, not %d", minPollOptions, maxPollOptions, n)
+	}
+	days := ns.PollDuration
+	if days == 0 {
+		days = defaultPollDuration
+	}
+	if days < 1 || days > maxPollDuration {
+		return pollPost{}, fmt.Errorf("polls last 1 to %d days, not %d", maxPollDuration, days)
+	}
+	return pollPost{
+		submitPost: ns.post(),
+		Text:       ns.Content,
+		Options:    ns.PollOptions,
+		Duration:   days,
+	}, nil
+}
+
+// submitResponse is the response of the submit endpoints.
+type submitResponse struct {
+	JSON struct {
+		Data struct {
+			ID   string
+			Name string
+			URL  string
+		}
+	}
+}
+
+// submission returns the Submission posted by ns. Only its IDs and URL
+// come from reddit, which answers image and video posts with neither.
+func (r *submitResponse) submission(ns *NewSubmission) *Submission {
+	d := r.JSON.Data
+	s := &Submission{
+		Title:         ns.Title,
+		Subreddit:     ns.Subreddit,
+		URL:           d.URL,
+		ID:            d.ID,
+		FullID:        d.Name,
+		IsSelf:        ns.Self && ns.Media == nil && len(ns.Gallery) == 0,
+		IsNSFW:        ns.NSFW,
+		IsSpoiler:     ns.Spoiler,
+		LinkFlairText: ns.FlairText,
+	}
+	if s.IsSelf {
+		s.Selftext = ns.Content
+	}
+
+	// The gallery and poll endpoints return the fullname as the ID.
+	if f, err := ParseFullname(d.ID); err == nil {
+		s.ID, s.FullID = f.ID(), string(f)
+	} else if s.FullID == "" && s.ID != "" {
+		s.FullID = string(NewFullname(KindLink, s.ID))
+	}
+	return s
+}
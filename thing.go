@@ -0,0 +1,167 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Kind is the type of a Thing, which prefixes its Fullname.
+type Kind string
+
+const (
+	KindComment   Kind = "t1"
+	KindAccount   Kind = "t2"
+	KindLink      Kind = "t3"
+	KindMessage   Kind = "t4"
+	KindSubreddit Kind = "t5"
+	KindAward     Kind = "t6"
+	KindMore      Kind = "more"
+)
+
+// Fullname is the ID of a thing prefixed by its Kind, e.g. "t3_15bfi0".
+type Fullname string
+
+// NewFullname returns the Fullname of the thing of the given kind and ID.
+func NewFullname(kind Kind, id string) Fullname {
+	return Fullname(string(kind) + "_" + id)
+}
+
+// ParseFullname parses and validates a fullname.
+func ParseFullname(s string) (Fullname, error) {
+	f := Fullname(s)
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	return f, nil
+}
+
+// Validate returns an error unless f is made of a known kind from t1 to t6
+// and a base 36 ID.
+func (f Fullname) Validate() error {
+	kind, id, ok := strings.Cut(string(f), "_")
+	if !ok || len(kind) != 2 || kind[0] != 't' || kind[1] < '1' || kind[1] > '6' {
+		return fmt.Errorf("invalid fullname %q: unknown kind", string(f))
+	}
+	if id == "" {
+		return fmt.Errorf("invalid fullname %q: empty ID", string(f))
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') {
+			return fmt.Errorf("invalid fullname %q: ID is not base 36", string(f))
+		}
+	}
+	return nil
+}
+
+// Kind returns the kind prefix of f.
+func (f Fullname) Kind() Kind {
+	kind, _, _ := strings.Cut(string(f), "_")
+	return Kind(kind)
+}
+
+// ID returns f without its kind prefix.
+func (f Fullname) ID() string {
+	_, id, _ := strings.Cut(string(f), "_")
+	return id
+}
+
+func (f Fullname) String() string {
+	return string(f)
+}
+
+// Thing is an item of a reddit listing. Data holds a *Comment, *Redditor,
+// *Submission, *Message, *Subreddit or *MoreComments depending on Kind, or
+// the raw json.RawMessage for other kinds.
+type Thing struct {
+	Kind Kind
+	Data interface{}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Thing) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Kind Kind            `json:"kind"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var data interface{}
+	switch raw.Kind {
+	case KindComment:
+		data = new(Comment)
+	case KindAccount:
+		data = new(Redditor)
+	case KindLink:
+		data = new(Submission)
+	case KindMessage:
+		data = new(Message)
+	case KindSubreddit:
+		data = new(Subreddit)
+	case KindMore:
+		data = new(MoreComments)
+	default:
+		*t = Thing{Kind: raw.Kind, Data: raw.Data}
+		return nil
+	}
+	if err := json.Unmarshal(raw.Data, data); err != nil {
+		return err
+	}
+	*t = Thing{Kind: raw.Kind, Data: data}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Thing) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind Kind        `json:"kind"`
+		Data interface{} `json:"data"`
+	}{t.Kind, t.Data})
+}
+
+// Fullname returns the fullname of the thing, if known.
+func (t Thing) Fullname() Fullname {
+	switch d := t.Data.(type) {
+	case *Comment:
+		return Fullname(d.FullID)
+	case *Redditor:
+		return NewFullname(KindAccount, d.ID)
+	case *Submission:
+		return Fullname(d.FullID)
+	case *Message:
+		return Fullname(d.FullID)
+	case *Subreddit:
+		return Fullname(d.FullID)
+	case *MoreComments:
+		return Fullname(d.FullID)
+	}
+	return ""
+}
+
+// Comment returns the comment held by the thing, or nil.
+func (t Thing) Comment() *Comment {
+	c, _ := t.Data.(*Comment)
+	return c
+}
+
+// Submission returns the submission held by the thing, or nil.
+func (t Thing) Submission() *Submission {
+	s, _ := t.Data.(*Submission)
+	return s
+}
+
+// thingData returns the data of t, which must be of type T.
+func thingData[T any](t *Thing) (T, error) {
+	d, ok := t.Data.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("unexpected thing of kind %q", t.Kind)
+	}
+	return d, nil
+}
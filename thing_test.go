@@ -0,0 +1,88 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseFullname(t *testing.T) {
+	f, err := ParseFullname("t3_15bfi0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Kind() != KindLink || f.ID() != "15bfi0" || f != NewFullname(KindLink, "15bfi0") {
+		t.Errorf("unexpected fullname: %q, %q", f.Kind(), f.ID())
+	}
+
+	for _, s := range []string{"", "15bfi0", "t7_abc", "t3_", "t3_ABC", "t3-abc", "more_abc"} {
+		if _, err := ParseFullname(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestThingJSON(t *testing.T) {
+	const overview = `{"kind": "Listing", "data": {"after": "t3_b", "children": [
+		{"kind": "t1", "data": {"name": "t1_a", "body": "a comment"}},
+		{"kind": "t3", "data": {"name": "t3_b", "title": "a link"}},
+		{"kind": "t4", "data": {"name": "t4_c", "subject": "a message"}},
+		{"kind": "t5", "data": {"name": "t5_d", "display_name": "golang"}},
+		{"kind": "t2", "data": {"id": "e", "name": "gopher"}},
+		{"kind": "t6", "data": {"id": "f"}}
+	]}}`
+
+	var l listing
+	if err := json.Unmarshal([]byte(overview), &l); err != nil {
+		t.Fatal(err)
+	}
+	things, err := l.things()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(things) != 6 {
+		t.Fatalf("unexpected things: %+v", things)
+	}
+
+	if c := things[0].Comment(); c == nil || c.Body != "a comment" {
+		t.Errorf("unexpected comment: %+v", things[0])
+	}
+	if s := things[1].Submission(); s == nil || s.Title != "a link" || things[1].Comment() != nil {
+		t.Errorf("unexpected submission: %+v", things[1])
+	}
+	if m, ok := things[2].Data.(*Message); !ok || m.Subject != "a message" {
+		t.Errorf("unexpected message: %+v", things[2])
+	}
+	if s, ok := things[3].Data.(*Subreddit); !ok || s.Name != "golang" {
+		t.Errorf("unexpected subreddit: %+v", things[3])
+	}
+	if _, ok := things[5].Data.(json.RawMessage); !ok || things[5].Kind != KindAward {
+		t.Errorf("unexpected award: %+v", things[5])
+	}
+
+	want := []Fullname{"t1_a", "t3_b", "t4_c", "t5_d", "t2_e", ""}
+	for i, th := range things {
+		if th.Fullname() != want[i] {
+			t.Errorf("thing %d: got fullname %q, want %q", i, th.Fullname(), want[i])
+		}
+	}
+
+	if s, _ := l.submissions(); len(s) != 1 || s[0].FullID != "t3_b" {
+		t.Errorf("unexpected submissions: %+v", s)
+	}
+	if c, _ := l.comments(); len(c) != 1 || c[0].FullID != "t1_a" {
+		t.Errorf("unexpected comments: %+v", c)
+	}
+
+	b, err := json.Marshal(things[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var th Thing
+	if err := json.Unmarshal(b, &th); err != nil || th.Submission() == nil || th.Submission().Title != "a link" {
+		t.Errorf("unexpected round trip: %s, %v", b, err)
+	}
+}
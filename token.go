@@ -0,0 +1,242 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenDuration is how long an authorization granted through AuthCodeURL
+// lasts.
+type TokenDuration string
+
+const (
+	// TemporaryDuration grants an access token valid for one hour.
+	TemporaryDuration TokenDuration = "temporary"
+	// PermanentDuration also grants a refresh token, which is used to
+	// obtain new access tokens once they expire.
+	PermanentDuration TokenDuration = "permanent"
+)
+
+// notifyTokenSource refreshes the token of an OAuthSession once it
+// expires and reports every new token to the session.
+type notifyTokenSource struct {
+	mu sync.Mutex
+	// refresh returns a new token in place of the expired one.
+	refresh func(context.Context, *oauth2.Token) (*oauth2.Token, error)
+	last    *oauth2.Token
+	session *OAuthSession
+}
+
+func (s *notifyTokenSource) Token() (*oauth2.Token, error) {
+	return s.tokenContext(s.session.baseContext())
+}
+
+// tokenContext returns the last token, refreshing it with ctx if expired.
+// The session is told of a new token once the lock is released, so that
+// its callbacks may use the session.
+func (s *notifyTokenSource) tokenContext(ctx context.Context) (*oauth2.Token, error) {
+	s.mu.Lock()
+	if s.last.Valid() {
+		defer s.mu.Unlock()
+		return s.last, nil
+	}
+	t, err := s.refresh(ctx, s.last)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, tokenError(err)
+	}
+	prev := s.last
+	s.last = t
+	s.mu.Unlock()
+
+	// A refresh may only extend the expiry of the same access token, which
+	// is still worth saving.
+	if prev == nil || t.AccessToken != prev.AccessToken || t.RefreshToken != prev.RefreshToken || !t.Expiry.Equal(prev.Expiry) {
+		s.session.tokenRefreshed(t)
+	}
+	return t, nil
+}
+
+// current returns the last token without refreshing it.
+func (s *notifyTokenSource) current() *oauth2.Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// tokenTransport authorizes requests with the token of a session. Unlike
+// oauth2.Transport, it refreshes the token with the context of the request.
+type tokenTransport struct {
+	source *notifyTokenSource
+	base   http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.source.tokenContext(t.source.session.authContext(req.Context()))
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	tok.SetAuthHeader(r)
+	return t.base.RoundTrip(r)
+}
+
+// ErrNoToken is returned by a TokenStore holding no token.
+var ErrNoToken = errors.New("no token stored")
+
+// TokenStore persists the OAuth token of a session across restarts.
+type TokenStore interface {
+	// Load returns the stored token, or ErrNoToken.
+	Load() (*oauth2.Token, error)
+	// Save replaces the stored token. The scopes granted to the token, held
+	// by t.Extra("scope"), should be kept for Load to return them as well:
+	// a session cannot enforce scopes it does not know. So should
+	// t.Extra("grant_type") and t.Extra("device_id") of application-only
+	// tokens, which tell how to fetch new ones.
+	Save(*oauth2.Token) error
+	// Delete removes the stored token.
+	Delete() error
+}
+
+// FileTokenStore is a TokenStore keeping the token as JSON in a file only
+// readable by its owner.
+type FileTokenStore struct {
+	Path string
+}
+
+// storedToken is the JSON form of a token in a FileTokenStore. It adds the
+// granted scopes and the grant of application-only tokens, which the JSON
+// form of oauth2.Token drops.
+type storedToken struct {
+	*oauth2.Token
+	Scope     string `json:"scope,omitempty"`
+	GrantType string `json:"grant_type,omitempty"`
+	DeviceID  string `json:"device_id,omitempty"`
+}
+
+// NewFileTokenStore returns a FileTokenStore using the file at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+// Load reads the token from the file.
+func (s *FileTokenStore) Load() (*oauth2.Token, error) {
+	b, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st := storedToken{Token: new(oauth2.Token)}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	extra := make(map[string]interface{})
+	for k, v := range map[string]string{
+		"scope":      st.Scope,
+		"grant_type": st.GrantType,
+		"device_id":  st.DeviceID,
+	} {
+		if v != "" {
+			extra[k] = v
+		}
+	}
+	if len(extra) > 0 {
+		return st.Token.WithExtra(extra), nil
+	}
+	return st.Token, nil
+}
+
+// Save atomically writes the token to the file with 0600 permissions.
+func (s *FileTokenStore) Save(t *oauth2.Token) error {
+	st := storedToken{Token: t}
+	st.Scope, _ = t.Extra("scope").(string)
+	st.GrantType, _ = t.Extra("grant_type").(string)
+	st.DeviceID, _ = t.Extra("device_id").(string)
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+// Delete removes the file.
+func (s *FileTokenStore) Delete() error {
+	err := os.Remove(s.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MemoryTokenStore is a TokenStore keeping the token in memory.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load returns a copy of the stored token.
+func (s *MemoryTokenStore) Load() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, ErrNoToken
+	}
+	t := *s.token
+	return &t, nil
+}
+
+// Save stores a copy of the token.
+func (s *MemoryTokenStore) Save(t *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *t
+	s.token = &c
+	return nil
+}
+
+// Delete forgets the stored token.
+func (s *MemoryTokenStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+	return nil
+}
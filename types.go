@@ -4,6 +4,15 @@
 
 package geddit
 
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
 // vote represents the three possible states of a vote on reddit.
 type Vote string
 
@@ -26,18 +35,99 @@ type NewSubmission struct {
 	Self        bool
 	SendReplies bool
 	Resubmit    bool
-	Save        bool
-	Captcha     *Captcha
+	// Save saves the submission once posted, which requires the "save"
+	// OAuth scope. If saving fails, Submit returns the posted submission
+	// along with the error. It is ignored by image and video posts, which
+	// reddit creates asynchronously.
+	Save    bool
+	Captcha *Captcha
+
+	// FlairID is the link flair template of the submission, and FlairText
+	// its text if the template is editable.
+	FlairID   string
+	FlairText string
+	NSFW      bool
+	Spoiler   bool
+	// CollectionID adds the submission to a collection of the subreddit.
+	CollectionID string
+	// EventStart and EventEnd, if set, make the submission an event.
+	EventStart time.Time
+	EventEnd   time.Time
+
+	// PollOptions, if set, makes the submission a poll of 2 to 6 options
+	// open for PollDuration days, from 1 to 7 or 3 if zero, and Content is
+	// the text of the poll. Polls are only supported by OAuthSession.
+	PollOptions  []string
+	PollDuration int
+
+	// Media, if set, makes the submission a native image or video post
+	// of the given MediaKind, and Content is ignored. Media posts are only
+	// supported by OAuthSession.
+	Media     *Upload
+	MediaKind MediaKind
+	// VideoPoster is the thumbnail image of a video post, which reddit
+	// requires.
+	VideoPoster *Upload
+	// Gallery, if set, makes the submission a gallery of images.
+	Gallery []*GalleryImage
 }
 
 // NewLinkSubmission returns a NewSubmission with parameters appropriate for a link submission
 func NewLinkSubmission(sr, title, link string, replies bool, c *Captcha) *NewSubmission {
-	return &NewSubmission{sr, title, link, false, replies, true, true, c}
+	return &NewSubmission{Subreddit: sr, Title: title, Content: link, SendReplies: replies, Resubmit: true, Captcha: c}
 }
 
 // NewTextSubmission returns a NewSubmission with parameters appropriate for a text submission
 func NewTextSubmission(sr, title, text string, replies bool, c *Captcha) *NewSubmission {
-	return &NewSubmission{sr, title, text, true, replies, true, true, c}
+	return &NewSubmission{Subreddit: sr, Title: title, Content: text, Self: true, SendReplies: replies, Resubmit: true, Captcha: c}
+}
+
+// NewImageSubmission returns a NewSubmission with parameters appropriate for an image submission
+func NewImageSubmission(sr, title string, image *Upload, replies bool) *NewSubmission {
+	return &NewSubmission{Subreddit: sr, Title: title, Media: image, MediaKind: ImageMedia, SendReplies: replies, Resubmit: true}
+}
+
+// NewVideoSubmission returns a NewSubmission with parameters appropriate for a video submission
+func NewVideoSubmission(sr, title string, video, poster *Upload, replies bool) *NewSubmission {
+	return &NewSubmission{Subreddit: sr, Title: title, Media: video, MediaKind: VideoMedia, VideoPoster: poster, SendReplies: replies, Resubmit: true}
+}
+
+// NewGallerySubmission returns a NewSubmission with parameters appropriate for a gallery submission
+func NewGallerySubmission(sr, title string, images []*GalleryImage, replies bool) *NewSubmission {
+	return &NewSubmission{Subreddit: sr, Title: title, Gallery: images, SendReplies: replies, Resubmit: true}
+}
+
+// NewPollSubmission returns a NewSubmission with parameters appropriate for a poll submission
+func NewPollSubmission(sr, title, text string, options []string, days int, replies bool) *NewSubmission {
+	return &NewSubmission{Subreddit: sr, Title: title, Content: text, Self: true, PollOptions: options, PollDuration: days, SendReplies: replies, Resubmit: true}
+}
+
+// MediaKind is the kind of a media submission.
+type MediaKind string
+
+const (
+	ImageMedia MediaKind = "image"
+	VideoMedia MediaKind = "video"
+	// VideoGIFMedia is a video without sound, shown by reddit as a GIF.
+	VideoGIFMedia MediaKind = "videogif"
+)
+
+// Upload is a file to upload to reddit.
+type Upload struct {
+	// Name is the file name, such as "gopher.png". It defaults to a name
+	// made from MIMEType.
+	Name     string
+	MIMEType string
+	// Body is streamed to the storage. It is sent along with its length if
+	// it is an io.Seeker, such as an *os.File, or has a Len method.
+	Body io.Reader
+}
+
+// GalleryImage is an image of a gallery submission.
+type GalleryImage struct {
+	Image       *Upload
+	Caption     string
+	OutboundURL string
 }
 
 // PopularitySort represents the possible ways to sort submissions by popularity.
@@ -88,3 +178,40 @@ type Deleter interface {
 type Replier interface {
 	replyID() string
 }
+
+// Edited tells whether and when a submission or comment was edited. reddit
+// sends false for things never edited and the time of the last edit
+// otherwise, or true for some old things.
+type Edited struct {
+	Edited bool
+	// Time is the time of the last edit, zero if unknown.
+	Time time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Edited) UnmarshalJSON(b []byte) error {
+	switch string(bytes.TrimSpace(b)) {
+	case "null", "false":
+		*e = Edited{}
+		return nil
+	case "true":
+		*e = Edited{Edited: true}
+		return nil
+	}
+
+	var secs float64
+	if err := json.Unmarshal(b, &secs); err != nil {
+		return err
+	}
+	sec, frac := math.Modf(secs)
+	*e = Edited{Edited: true, Time: time.Unix(int64(sec), int64(frac*1e9)).UTC()}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, using reddit's representation.
+func (e Edited) MarshalJSON() ([]byte, error) {
+	if e.Time.IsZero() {
+		return strconv.AppendBool(nil, e.Edited), nil
+	}
+	return strconv.AppendFloat(nil, float64(e.Time.UnixNano())/1e9, 'f', -1, 64), nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2012 Jimmy Zelinskie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geddit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MediaAsset is a file uploaded to reddit's media storage.
+type MediaAsset struct {
+	// ID identifies the asset in gallery submissions.
+	ID string
+	// URL is the location of the uploaded file, used by image and video
+	// submissions.
+	URL string
+	// WebsocketURL is where reddit announces the submission made of the
+	// asset once it is processed.
+	WebsocketURL string
+}
+
+// mediaLease is the response of /api/media/asset.json: a form to upload
+// the file with, and the asset the file becomes.
+type mediaLease struct {
+	Args struct {
+		Action string
+		Fields []struct {
+			Name  string
+			Value string
+		}
+	}
+	Asset struct {
+		AssetID      string `json:"asset_id"`
+		WebsocketURL string `json:"websocket_url"`
+	}
+}
+
+// UploadMedia uploads an image or video to reddit's media storage, to be
+// used by a submission.
+func (o *OAuthSession) UploadMedia(u *Upload) (*MediaAsset, error) {
+	return o.UploadMediaContext(context.Background(), u)
+}
+
+// UploadMediaContext is like UploadMedia but with a context.
+func (o *OAuthSession) UploadMediaContext(ctx context.Context, u *Upload) (*MediaAsset, error) {
+	name := u.Name
+	if name == "" {
+		name = "media"
+		if exts, _ := mime.ExtensionsByType(u.MIMEType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+
+	// Lease an upload form.
+	var lease mediaLease
+	v := url.Values{
+		"filepath": {name},
+		"mimetype": {u.MIMEType},
+	}
+	err := o.postBody(ctx, "submit", o.opts.oauthURL()+"/api/media/asset.json", v, &lease)
+	if err != nil {
+		return nil, err
+	}
+	action, err := url.Parse(lease.Args.Action)
+	if err != nil {
+		return nil, err
+	}
+	if action.Scheme == "" {
+		action.Scheme = "https"
+	}
+
+	var key string
+	for _, f := range lease.Args.Fields {
+		if f.Name == "key" {
+			key = f.Value
+		}
+	}
+	if key == "" {
+		return nil, errors.New("media upload lease lacks a key")
+	}
+
+	// Stream the file with the leased form fields, so that large videos
+	// are not held in memory.
+	boundary := multipart.NewWriter(nil).Boundary()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeUploadForm(pw, boundary, &lease, name, u.MIMEType, u.Body))
+	}()
+	req, err := http.NewRequestWithContext(ctx, "POST", action.String(), pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	if size, ok := uploadSize(u.Body); ok {
+		var n byteCounter
+		if err := writeUploadForm(&n, boundary, &lease, name, u.MIMEType, strings.NewReader("")); err == nil {
+			req.ContentLength = int64(n) + size
+		}
+	}
+
+	// The storage is not reddit's API: send the upload with neither the
+	// OAuth token nor the API rate limit. The streamed body cannot be sent
+	// twice either.
+	opts := o.opts.unlimited()
+	opts.retry = RetryPolicy{}
+	if _, _, err := opts.do(opts.httpClient(), req); err != nil {
+		return nil, fmt.Errorf("media upload failed: %w", err)
+	}
+
+	return &MediaAsset{
+		ID:           lease.Asset.AssetID,
+		URL:          strings.TrimSuffix(action.String(), "/") + "/" + key,
+		WebsocketURL: lease.Asset.WebsocketURL,
+	}, nil
+}
+
+// writeUploadForm writes to dst the multipart form uploading body with the
+// fields of lease.
+func writeUploadForm(dst io.Writer, boundary string, lease *mediaLease, name, mimeType string, body io.Reader) error {
+	w := multipart.NewWriter(dst)
+	if err := w.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, f := range lease.Args.Fields {
+		if err := w.WriteField(f.Name, f.Value); err != nil {
+			return err
+		}
+	}
+	part, err := w.CreatePart(map[string][]string{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename=%q`, name)},
+		"Content-Type":        {mimeType},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, body); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// uploadSize returns the number of bytes left in r, if known without
+// reading it.
+func uploadSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false
+		}
+		if _, err := r.Seek(cur, io.SeekStart); err != nil {
+			return 0, false
+		}
+		return end - cur, true
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	}
+	return 0, false
+}
+
+// byteCounter is an io.Writer counting the bytes written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// submitMedia uploads the media of ns and sets the form values of an
+// image or video submission.
+func (o *OAuthSession) submitMedia(ctx context.Context, ns *NewSubmission, v url.Values) error {
+	kind := ns.MediaKind
+	if kind == "" {
+		kind = ImageMedia
+	}
+	if kind != ImageMedia && ns.VideoPoster == nil {
+		return errors.New("video submissions require a VideoPoster")
+	}
+
+	asset, err := o.UploadMediaContext(ctx, ns.Media)
+	if err != nil {
+		return err
+	}
+	v.Set("kind", string(kind))
+	v.Set("url", asset.URL)
+	v.Del("text")
+
+	if kind != ImageMedia {
+		poster, err := o.UploadMediaContext(ctx, ns.VideoPoster)
+		if err != nil {
+			return err
+		}
+		v.Set("video_poster_url", poster.URL)
+	}
+	return nil
+}
+
+// galleryPost is the JSON body of /api/submit_gallery_post.json.
+type galleryPost struct {
+	submitPost
+	Items []galleryItem `json:"items"`
+}
+
+type galleryItem struct {
+	MediaID     string `json:"media_id"`
+	Caption     string `json:"caption"`
+	OutboundURL string `json:"outbound_url"`
+}
+
+// submitGallery uploads the images of ns and submits them as a gallery.
+func (o *OAuthSession) submitGallery(ctx context.Context, ns *NewSubmission, r *submitResponse) error {
+	post := galleryPost{submitPost: ns.post()}
+	for _, item := range ns.Gallery {
+		asset, err := o.UploadMediaContext(ctx, item.Image)
+		if err != nil {
+			return err
+		}
+		post.Items = append(post.Items, galleryItem{
+			MediaID:     asset.ID,
+			Caption:     item.Caption,
+			OutboundURL: item.OutboundURL,
+		})
+	}
+	return o.postJSON(ctx, "submit", o.opts.oauthURL()+"/api/submit_gallery_post.json", post, r)
+}